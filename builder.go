@@ -2,7 +2,6 @@ package database
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -46,34 +45,42 @@ func struct2name(name string) string {
 	return string(result)
 }
 
+// Select 查询多条
 func Select[T any](db pgxscan.Querier, sb sq.SelectBuilder) ([]T, error) {
+	return SelectContext[T](context.Background(), db, sb)
+}
+
+// SelectContext 查询多条
+func SelectContext[T any](ctx context.Context, db pgxscan.Querier, sb sq.SelectBuilder) ([]T, error) {
+	ctx = withRead(WithOperation(ctx, "", "database.Select"))
 	sql, args, err := sb.ToSql()
 	if err != nil {
-		execErr(errors.Join(err, errors.New("error building SQL")), "", "database.Select")
-		return nil, err
+		return nil, clientOf(db).buildErr(ctx, err, "", "database.Select")
 	}
 	var results []T
-	err = pgxscan.Select(context.Background(), db, &results, sql, args...)
+	err = pgxscan.Select(ctx, db, &results, sql, args...)
 	if err != nil {
-		err = errors.Join(err,
-			fmt.Errorf("error executing SQL:\n#### SQL:\n%s\n#### Args:\n%v", sql, args))
-		execErr(err, "", "database.Select")
+		err = clientOf(db).queryErr(ctx, err, "", "database.Select", sql, args)
 	}
 	return results, err
 }
 
+// Get 查询单条
 func Get[T any](db pgxscan.Querier, sb sq.SelectBuilder) (*T, error) {
+	return GetContext[T](context.Background(), db, sb)
+}
+
+// GetContext 查询单条
+func GetContext[T any](ctx context.Context, db pgxscan.Querier, sb sq.SelectBuilder) (*T, error) {
+	ctx = withRead(WithOperation(ctx, "", "database.Get"))
 	sql, args, err := sb.ToSql()
 	if err != nil {
-		execErr(errors.Join(err, errors.New("error building SQL")), "", "database.Get")
-		return nil, err
+		return nil, clientOf(db).buildErr(ctx, err, "", "database.Get")
 	}
 	var result T
-	err = pgxscan.Get(context.Background(), db, &result, sql, args...)
+	err = pgxscan.Get(ctx, db, &result, sql, args...)
 	if err != nil {
-		err = errors.Join(err,
-			fmt.Errorf("error executing SQL:\n#### SQL:\n%s\n#### Args:\n%v", sql, args))
-		execErr(err, "", "database.Get")
+		err = clientOf(db).queryErr(ctx, err, "", "database.Get", sql, args)
 	}
 	return &result, err
 }
@@ -82,38 +89,63 @@ func Get[T any](db pgxscan.Querier, sb sq.SelectBuilder) (*T, error) {
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func GetAll[T any](table string, page, size uint64, hook func(sq.SelectBuilder) sq.SelectBuilder) ([]T, int) {
+	urs, count, _ := GetAllContext[T](context.Background(), table, page, size, hook)
+	return urs, count
+}
+
+// GetAllContext 获取某表全部数据 通过 hook 钩子函数进行拓展
+func GetAllContext[T any](ctx context.Context, table string, page, size uint64, hook func(sq.SelectBuilder) sq.SelectBuilder) ([]T, int, error) {
+	ctx = withRead(WithOperation(ctx, table, "GetAll"))
 	page, size = sanitizePageAndSize(page, size)
 	var count int
 	sb := hook(psql.Select("COUNT(*)").From(table))
-	sql, args, _ := builder.Delete(sb, "OrderByParts").(sq.SelectBuilder).ToSql()
-
-	err := pool.Client.QueryRow(context.Background(), sql, args...).Scan(&count)
-	execErr(err, table, "GetAll - Count")
+	sql, args, err := builder.Delete(sb, "OrderByParts").(sq.SelectBuilder).ToSql()
+	if err != nil {
+		return nil, 0, pool.buildErr(ctx, err, table, "GetAll - Count")
+	}
+	err = pool.QueryRow(ctx, sql, args...).Scan(&count)
+	if err != nil {
+		return nil, 0, pool.queryErr(ctx, err, table, "GetAll - Count", sql, args)
+	}
 
 	b := hook(psql.Select("*").From(table))
-	sql, args, _ = b.Limit(size).Offset((page - 1) * size).ToSql()
-	row, _ := pool.Client.Query(context.Background(), sql, args...)
-	urs, err := pgx.CollectRows(row, RowToStructByName[T])
-	execErr(err, table, "GetAll - Pagination")
-	return urs, count
+	sql, args, err = b.Limit(size).Offset((page - 1) * size).ToSql()
+	if err != nil {
+		return nil, count, pool.buildErr(ctx, err, table, "GetAll - Pagination")
+	}
+	urs, err := collectRows(ctx, pool, sql, args, RowToStructByName[T])
+	return urs, count, pool.queryErr(ctx, err, table, "GetAll - Pagination", sql, args)
 }
 
 func GetAllByFields[T any](table string, fields []string, sort []string, page, size uint64, hook func(sq.SelectBuilder) sq.SelectBuilder) ([]T, int) {
+	urs, count, _ := GetAllByFieldsContext[T](context.Background(), table, fields, sort, page, size, hook)
+	return urs, count
+}
+
+// GetAllByFieldsContext 按指定字段与排序分页获取某表数据
+func GetAllByFieldsContext[T any](ctx context.Context, table string, fields []string, sort []string, page, size uint64, hook func(sq.SelectBuilder) sq.SelectBuilder) ([]T, int, error) {
+	ctx = withRead(WithOperation(ctx, table, "GetAllByFields"))
 	page, size = sanitizePageAndSize(page, size)
 	var count int
 
 	// 使用传入的字段列表构建 COUNT 查询
-	sql, args, _ := hook(psql.Select("COUNT(*)").From(table)).ToSql()
-	err := pool.Client.QueryRow(context.Background(), sql, args...).Scan(&count)
-	execErr(err, table, "GetAll - Count")
+	sql, args, err := hook(psql.Select("COUNT(*)").From(table)).ToSql()
+	if err != nil {
+		return nil, 0, pool.buildErr(ctx, err, table, "GetAll - Count")
+	}
+	err = pool.QueryRow(ctx, sql, args...).Scan(&count)
+	if err != nil {
+		return nil, 0, pool.queryErr(ctx, err, table, "GetAll - Count", sql, args)
+	}
 
 	// 使用传入的字段列表构建 SELECT 查询
 	b := hook(psql.Select(fields...).From(table))
-	sql, args, _ = b.Limit(size).Offset((page - 1) * size).OrderBy(sort...).ToSql()
-	row, err := pool.Client.Query(context.Background(), sql, args...)
-	urs, err := pgx.CollectRows(row, RowToStructByName[T])
-	execErr(err, table, "GetAll - Pagination")
-	return urs, count
+	sql, args, err = b.Limit(size).Offset((page - 1) * size).OrderBy(sort...).ToSql()
+	if err != nil {
+		return nil, count, pool.buildErr(ctx, err, table, "GetAll - Pagination")
+	}
+	urs, err := collectRows(ctx, pool, sql, args, RowToStructByName[T])
+	return urs, count, pool.queryErr(ctx, err, table, "GetAll - Pagination", sql, args)
 }
 
 // GetAllByFieldsCte 使用CTE实现将三个查询合并到同一语句来执行需要对主表JOIN和LIMIT的分页查询，
@@ -135,6 +167,23 @@ func GetAllByFields[T any](table string, fields []string, sort []string, page, s
 // JOIN (SELECT <id> FROM __cte_all_ids <LIMIT> <OFFSET>) as __cts_ids ON __cte_ids.<id> = <主表>.<id>
 // CROSS JOIN __cte_count
 func GetAllByFieldsCte[T any](
+	// 数据库连接对象
+	db pgxscan.Querier,
+	fromTable,
+	idField string,
+	sort []string,
+	page,
+	size uint64,
+	buildPreselect func(selectIdFrom sq.SelectBuilder) sq.SelectBuilder,
+	buildPrimary func(selectPrimaryFrom sq.SelectBuilder) sq.SelectBuilder,
+) ([]T, int, bool) {
+	res, count, err := GetAllByFieldsCteContext[T](context.Background(), db, fromTable, idField, sort, page, size, buildPreselect, buildPrimary)
+	return res, count, err == nil
+}
+
+// GetAllByFieldsCteContext 同 GetAllByFieldsCte，失败时返回错误
+func GetAllByFieldsCteContext[T any](
+	ctx context.Context,
 	// 数据库连接对象
 	db pgxscan.Querier,
 	// 主表名或表名+别名，如：trader或"trader t"
@@ -151,7 +200,8 @@ func GetAllByFieldsCte[T any](
 	buildPreselect func(selectIdFrom sq.SelectBuilder) sq.SelectBuilder,
 	// buildPrimary 主查询构建函数，不应当在主查询中完成条件筛选
 	buildPrimary func(selectPrimaryFrom sq.SelectBuilder) sq.SelectBuilder,
-) ([]T, int, bool) {
+) ([]T, int, error) {
+	ctx = withRead(WithOperation(ctx, fromTable, "database.GetAllByFieldsCte"))
 	// 没有获取到任何记录时返回默认总数 (page - 1) * size
 	// 实际上可以用RIGHT JOIN返回一个[总数=n 主记录=NULL]的行来获取总数，但是这样要额外处理主记录=NULL的情况
 	count := (page - 1) * size
@@ -203,28 +253,53 @@ func GetAllByFieldsCte[T any](
 
 	sql, args, err := primarySb.ToSql()
 	if err != nil {
-		execErr(errors.Join(err, errors.New("error building SQL")), "", "database.GetAllByFieldsCte")
-		return nil, 0, false
+		return nil, 0, clientOf(db).buildErr(ctx, err, "", "database.GetAllByFieldsCte")
 	}
-	row, err := db.Query(context.Background(), sql, args...)
+	row, err := db.Query(ctx, sql, args...)
 	if err != nil {
-		sqlErr := fmt.Errorf("error executing db.Query:\n#### SQL:\n%s\n#### Args:\n%v", sql, args)
-		execErr(errors.Join(err, sqlErr), "", "database.GetAllByFieldsCte")
+		return nil, 0, clientOf(db).queryErr(ctx, err, "", "database.GetAllByFieldsCte", sql, args)
 	}
 
-	var typ reflect.Type
-	{
-		var value *T
-		typ = reflect.TypeOf(value).Elem()
+	res, err := collectWithCount[T](row, countField, &count)
+	return res, int(count), clientOf(db).queryErr(ctx, err, "", "database.GetAllByFieldsCte", sql, args)
+}
+
+// SelectCountOver 查询多条并通过窗口函数 COUNT(*) OVER() 在同一语句中返回总数
+func SelectCountOver[T any](db pgxscan.Querier, sb sq.SelectBuilder) ([]T, int, error) {
+	return SelectCountOverContext[T](context.Background(), db, sb)
+}
+
+// SelectCountOverContext 查询多条并通过窗口函数 COUNT(*) OVER() 在同一语句中返回总数
+//
+// 窗口函数在 GROUP BY 之后、LIMIT/OFFSET 之前计算，总数为分组后的行数；
+// 当前页没有记录时无法得到总数，返回 0
+func SelectCountOverContext[T any](ctx context.Context, db pgxscan.Querier, sb sq.SelectBuilder) ([]T, int, error) {
+	ctx = withRead(WithOperation(ctx, "", "database.SelectCountOver"))
+	countField := "__pagination_count"
+	sql, args, err := sb.Column(`COUNT(*) OVER() AS "` + countField + `"`).ToSql()
+	if err != nil {
+		return nil, 0, clientOf(db).buildErr(ctx, err, "", "database.SelectCountOver")
 	}
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, clientOf(db).queryErr(ctx, err, "", "database.SelectCountOver", sql, args)
+	}
+
+	var count int64
+	res, err := collectWithCount[T](rows, countField, &count)
+	return res, int(count), clientOf(db).queryErr(ctx, err, "", "database.SelectCountOver", sql, args)
+}
 
+// collectWithCount 将每行扫描到 T，并将 countField 列写入 count
+func collectWithCount[T any](rows pgx.Rows, countField string, count any) ([]T, error) {
+	typ := reflect.TypeFor[T]()
 	customScanTargets := []custom_row2struct.CustomScanFromRow{
 		{
 			RowField: countField,
 			Ord:      0,
 		},
 	}
-	res, err := pgx.CollectRows(row, func(row pgx.CollectableRow) (T, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (T, error) {
 		var value T
 		fldDescs := row.FieldDescriptions()
 		nsf, err := custom_row2struct.LookupNamedStructFields(fldDescs, typ, customScanTargets)
@@ -234,7 +309,7 @@ func GetAllByFieldsCte[T any](
 		if err = nsf.RequireStructFieldsInRows(); err != nil {
 			return value, err
 		}
-		scanTargets, err := custom_row2struct.SetupCustomStructScanTargets(&value, nsf, &count)
+		scanTargets, err := custom_row2struct.SetupCustomStructScanTargets(&value, nsf, count)
 		if err != nil {
 			return value, err
 		}
@@ -243,25 +318,21 @@ func GetAllByFieldsCte[T any](
 		}
 		return value, nil
 	})
-
-	return res, int(count), execErr(err, "", "database.GetAllByFieldsCte")
 }
 
 // GetOneFromStructNameTable 获取某表一条数据 通过 hook 钩子函数进行拓展
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func GetOneFromStructNameTable[T any](cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, bool) {
+	urs, err := GetOneFromStructNameTableContext[T](context.Background(), cols, hook)
+	return urs, err == nil
+}
+
+// GetOneFromStructNameTableContext 获取以结构体名为表名的一条数据
+func GetOneFromStructNameTableContext[T any](ctx context.Context, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, error) {
 	var data T
 	tableName := struct2name(reflect2.TypeOf(data).String())
-	b := hook(psql.Select(cols...).From(tableName))
-	sql, args, _ := b.ToSql()
-
-	row, _ := pool.Client.Query(context.Background(), sql, args...)
-	urs, err := pgx.CollectOneRow(row, RowToStructByName[T])
-	if !execErr(err, tableName, "GetOne") {
-		return urs, false
-	}
-	return urs, true
+	return getOne(ctx, tableName, cols, hook, RowToStructByName[T], "GetOne")
 }
 
 // GetOne 获取某表一条数据 通过 hook 钩子函数进行拓展
@@ -270,15 +341,13 @@ func GetOneFromStructNameTable[T any](cols []string, hook func(sq.SelectBuilder)
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func GetOne[T any](tableName string, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, bool) {
-	b := hook(psql.Select(cols...).From(tableName))
-	sql, args, _ := b.ToSql()
+	urs, err := GetOneContext[T](context.Background(), tableName, cols, hook)
+	return urs, err == nil
+}
 
-	row, _ := pool.Client.Query(context.Background(), sql, args...)
-	urs, err := pgx.CollectOneRow(row, RowToStructByName[T])
-	if !execErr(err, tableName, "GetOne") {
-		return urs, false
-	}
-	return urs, true
+// GetOneContext 获取某表一条数据 通过 hook 钩子函数进行拓展
+func GetOneContext[T any](ctx context.Context, tableName string, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, error) {
+	return getOne(ctx, tableName, cols, hook, RowToStructByName[T], "GetOne")
 }
 
 // GetOne2 获取某表一条数据 通过 hook 钩子函数进行拓展
@@ -287,78 +356,148 @@ func GetOne[T any](tableName string, cols []string, hook func(sq.SelectBuilder)
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func GetOne2[T any](tableName string, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, bool) {
+	urs, err := GetOne2Context[T](context.Background(), tableName, cols, hook)
+	return urs, err == nil
+}
+
+// GetOne2Context 获取某表一条数据，使用 pgx.RowToStructByName
+func GetOne2Context[T any](ctx context.Context, tableName string, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder) (T, error) {
+	return getOne(ctx, tableName, cols, hook, pgx.RowToStructByName[T], "GetOne2")
+}
+
+func getOne[T any](ctx context.Context, tableName string, cols []string, hook func(sq.SelectBuilder) sq.SelectBuilder, fn pgx.RowToFunc[T], action string) (T, error) {
+	ctx = withRead(WithOperation(ctx, tableName, action))
+	var urs T
 	b := hook(psql.Select(cols...).From(tableName))
-	sql, args, _ := b.ToSql()
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return urs, pool.buildErr(ctx, err, tableName, action)
+	}
+
+	row, err := pool.Query(ctx, sql, args...)
+	if err == nil {
+		urs, err = pgx.CollectOneRow(row, fn)
+	}
+	return urs, pool.queryErr(ctx, err, tableName, action, sql, args)
+}
 
-	row, _ := pool.Client.Query(context.Background(), sql, args...)
-	urs, err := pgx.CollectOneRow(row, pgx.RowToStructByName[T])
-	if !execErr(err, tableName, "GetOne2") {
-		return urs, false
+// collectRows 执行查询并通过 fn 收集全部行
+func collectRows[T any](ctx context.Context, db pgxscan.Querier, sql string, args []any, fn pgx.RowToFunc[T]) ([]T, error) {
+	row, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
 	}
-	return urs, true
+	return pgx.CollectRows(row, fn)
 }
 
 // GetCount 获取某表数据条数
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func GetCount(table string, hook func(sq.SelectBuilder) sq.SelectBuilder) int {
-	var count int
-	sql, args, _ := hook(psql.Select("COUNT(*)").From(table)).ToSql()
-	err := pool.Client.QueryRow(context.Background(), sql, args...).Scan(&count)
-	execErr(err, table, "GetCount")
+	count, _ := GetCountContext(context.Background(), table, hook)
 	return count
 }
 
+// GetCountContext 获取某表数据条数
+func GetCountContext(ctx context.Context, table string, hook func(sq.SelectBuilder) sq.SelectBuilder) (int, error) {
+	ctx = withRead(WithOperation(ctx, table, "GetCount"))
+	var count int
+	sql, args, err := hook(psql.Select("COUNT(*)").From(table)).ToSql()
+	if err != nil {
+		return 0, pool.buildErr(ctx, err, table, "GetCount")
+	}
+	err = pool.QueryRow(ctx, sql, args...).Scan(&count)
+	return count, pool.queryErr(ctx, err, table, "GetCount", sql, args)
+}
+
 // Insert 为某表添加记录 返回是否成功
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func Insert[T any](hook func(sq.InsertBuilder) sq.InsertBuilder) (T, bool) {
+	ret, err := InsertContext[T](context.Background(), hook)
+	return ret, err == nil
+}
+
+// InsertContext 为某表添加记录并返回插入后的整行
+func InsertContext[T any](ctx context.Context, hook func(sq.InsertBuilder) sq.InsertBuilder) (T, error) {
 	var data T
 	tableName := struct2name(reflect2.TypeOf(data).String())
 	b := hook(psql.Insert(tableName)).Suffix("RETURNING *")
-	sql, args, _ := b.ToSql()
-	row, err := pool.Client.Query(context.Background(), sql, args...)
-	ret, err := pgx.CollectOneRow(row, RowToStructByName[T])
-	return ret, execErr(err, tableName, "Insert")
+	return queryOne[T](ctx, pool, tableName, "Insert", b)
 }
 
 // Update 为某表更新记录 返回是否成功
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func Update[T any](hook func(sq.UpdateBuilder) sq.UpdateBuilder) (T, bool) {
+	ret, err := UpdateContext[T](context.Background(), hook)
+	return ret, err == nil
+}
+
+// UpdateContext 为某表更新记录并返回更新后的整行
+func UpdateContext[T any](ctx context.Context, hook func(sq.UpdateBuilder) sq.UpdateBuilder) (T, error) {
 	var data T
 	tableName := struct2name(reflect2.TypeOf(data).String())
 	b := hook(psql.Update(tableName)).Suffix("RETURNING *")
-	sql, args, _ := b.ToSql()
+	return queryOne[T](ctx, pool, tableName, "Update", b)
+}
 
-	row, err := pool.Client.Query(context.Background(), sql, args...)
-	ret, err := pgx.CollectOneRow(row, RowToStructByName[T])
-	return ret, execErr(err, tableName, "Update")
+// queryOne 执行带 RETURNING 的语句并扫描首行
+func queryOne[T any](ctx context.Context, db pgxscan.Querier, tableName, action string, sb sq.Sqlizer) (T, error) {
+	ctx = WithOperation(ctx, tableName, action)
+	var ret T
+	sql, args, err := sb.ToSql()
+	if err != nil {
+		return ret, clientOf(db).buildErr(ctx, err, tableName, action)
+	}
+	row, err := db.Query(ctx, sql, args...)
+	if err == nil {
+		ret, err = pgx.CollectOneRow(row, RowToStructByName[T])
+	}
+	return ret, clientOf(db).queryErr(ctx, err, tableName, action, sql, args)
 }
 
 // UpdateTx 为某表更新记录 返回是否成功
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func UpdateTx[T any](tx pgx.Tx, hook func(sq.UpdateBuilder) sq.UpdateBuilder) bool {
+	return UpdateTxContext[T](context.Background(), tx, hook) == nil
+}
+
+// UpdateTxContext 在事务 tx 中为某表更新记录
+func UpdateTxContext[T any](ctx context.Context, tx pgx.Tx, hook func(sq.UpdateBuilder) sq.UpdateBuilder) error {
 	var data T
 	tableName := struct2name(reflect2.TypeOf(data).String())
+	ctx = WithOperation(ctx, tableName, "Update")
 	b := hook(psql.Update(tableName)).Suffix("RETURNING *")
-	sql, args, _ := b.ToSql()
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return pool.buildErr(ctx, err, tableName, "Update")
+	}
 
-	_, err := tx.Exec(context.Background(), sql, args...)
-	return execErr(err, tableName, "Update")
+	_, err = tx.Exec(ctx, sql, args...)
+	return pool.queryErr(ctx, err, tableName, "Update", sql, args)
 }
 
 // Delete 为某表删除记录 返回是否成功
 //
 // 文档地址 https://github.com/Masterminds/squirrel
 func Delete[T any](hook func(sq.DeleteBuilder) sq.DeleteBuilder) bool {
+	return DeleteContext[T](context.Background(), hook) == nil
+}
+
+// DeleteContext 为某表删除记录
+func DeleteContext[T any](ctx context.Context, hook func(sq.DeleteBuilder) sq.DeleteBuilder) error {
 	var data T
 	tableName := struct2name(reflect2.TypeOf(data).String())
+	ctx = WithOperation(ctx, tableName, "Delete")
 	b := hook(psql.Delete(tableName))
-	sql, args, _ := b.ToSql()
-	_, err := pool.Client.Exec(context.Background(), sql, args...)
-	return execErr(err, tableName, "Delete")
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return pool.buildErr(ctx, err, tableName, "Delete")
+	}
+	_, err = pool.Exec(ctx, sql, args...)
+	return pool.queryErr(ctx, err, tableName, "Delete", sql, args)
 }
 
 type Cte[T sq.Sqlizer] struct {
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -14,6 +17,16 @@ type FieldSchema struct {
 	GoType     reflect.Type // Go类型
 	PrimaryKey bool         // 是否主键
 	AutoIncr   bool         // 是否自增
+	Sensitive  bool         // 是否敏感字段，其参数在错误信息与日志中脱敏
+	ReadOnly   bool         // 只读，从不写入
+	InsertOnly bool         // 仅插入时写入，更新时跳过
+	Default    bool         // 插入时零值省略，由数据库默认值填充
+	NullZero   bool         // 零值写入 NULL
+	JSON       bool         // 写入前序列化为 JSON（json/jsonb）
+	Created    bool         // 创建时间
+	Updated    bool         // 更新时间
+	Version    bool         // 乐观锁版本号
+	SoftDelete bool         // 软删除标记
 }
 
 // TableSchema 表元数据
@@ -21,41 +34,96 @@ type TableSchema struct {
 	GoType        reflect.Type
 	TableName     string
 	Fields        []*FieldSchema
-	PrimaryKey    *FieldSchema
+	PrimaryKey    *FieldSchema               // 首个主键字段，联合主键见 PrimaryKeys
+	PrimaryKeys   []*FieldSchema             // 全部主键字段，按声明顺序
+	CreatedAt     *FieldSchema               // 创建时间字段
+	UpdatedAt     *FieldSchema               // 更新时间字段
+	SoftDelete    *FieldSchema               // 软删除字段
+	Version       *FieldSchema               // 乐观锁版本号字段
+	Relations     map[string]*RelationSchema // 关联，键为 Go 字段名
 	ColumnToField map[string]*FieldSchema
 }
 
-// 空接口实际上是具有两个指针的结构的语法糖：第一个指向有关类型的信息，第二个指向值
-// 可以使用结构体中字段偏移量来直接寻址该值的字段
-type interfaceMark struct {
-	typ   unsafe.Pointer
-	value unsafe.Pointer
-}
-
 var (
-	schemaCache = make(map[uintptr]*TableSchema) // 表缓存
+	schemaCache sync.Map // 表缓存 reflect.Type -> *TableSchema
 )
 
-// RegisterModel 注册表模型
+// tabler 自定义表名
+type tabler interface {
+	TableName() string
+}
+
+// RegisterModel 注册表模型，以相同表名重复注册时保留首次注册的结果
 //
-// Warning: 线程不安全
+// 未注册的模型会在首次使用时自动注册，仅在表名与默认规则不一致时需要显式注册，且应在首次使用前注册；
+// 已注册（包括自动注册）为其它表名时返回错误
 func RegisterModel[T any](tableName string) error {
-	var model T
-	typ := reflect.TypeOf(model)
+	typ := reflect.TypeFor[T]()
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
 	}
+	if existing, ok := schemaCache.Load(typ); ok {
+		return checkTableName(existing.(*TableSchema), tableName)
+	}
 
-	// 检查是否已注册
-	infMark := (*interfaceMark)(unsafe.Pointer(&typ))
-	//fmt.Println("cache", typ.String(), infMark.value)
-	if _, ok := schemaCache[uintptr(infMark.value)]; ok {
-		return nil
+	schema, err := buildSchema(typ, tableName)
+	if err != nil {
+		return err
+	}
+	if existing, loaded := storeSchema(typ, schema); loaded {
+		return checkTableName(existing, tableName)
+	}
+	return nil
+}
+
+// RegisterSensitive 注册模型并立即登记其 sensitive 字段
+//
+// 依赖自动注册的模型在首次经由 orm 使用前不会登记敏感列，此前对该表的原生 SQL 参数不会脱敏，
+// 含敏感字段的模型应在启动时调用本函数或 RegisterModel
+func RegisterSensitive[T any]() error {
+	_, err := LookupSchema((*T)(nil))
+	return err
+}
+
+// storeSchema 缓存 schema 并登记其敏感列，已有缓存时返回已缓存的 schema 与 true
+func storeSchema(typ reflect.Type, schema *TableSchema) (*TableSchema, bool) {
+	if actual, loaded := schemaCache.LoadOrStore(typ, schema); loaded {
+		return actual.(*TableSchema), true
+	}
+	for _, field := range schema.Fields {
+		if field.Sensitive {
+			registerTableSensitiveColumns(schema.TableName, field.ColumnName)
+		}
+	}
+	return schema, false
+}
+
+// checkTableName 检查已注册的表名与 tableName 是否一致
+func checkTableName(schema *TableSchema, tableName string) error {
+	if schema.TableName != quoteTable(tableName) {
+		return fmt.Errorf("database: model %s is already registered as %s", schema.GoType, schema.TableName)
+	}
+	return nil
+}
+
+// quoteTable 为表名的每一段加引号，如 public.users 为 "public"."users"
+func quoteTable(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.Trim(part, `"`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// buildSchema 解析结构体生成表元数据
+func buildSchema(typ reflect.Type, tableName string) (*TableSchema, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("database: model %s is not a struct", typ)
 	}
 
 	schema := &TableSchema{
 		GoType:        typ,
-		TableName:     "\"" + tableName + "\"",
+		TableName:     quoteTable(tableName),
 		ColumnToField: make(map[string]*FieldSchema),
 	}
 
@@ -68,6 +136,19 @@ func RegisterModel[T any](tableName string) error {
 			continue
 		}
 
+		// 关联字段不是列
+		if tag, ok := field.Tag.Lookup("rel"); ok {
+			rel, err := parseRelation(typ, field, tag)
+			if err != nil {
+				return nil, err
+			}
+			if schema.Relations == nil {
+				schema.Relations = make(map[string]*RelationSchema)
+			}
+			schema.Relations[rel.Name] = rel
+			continue
+		}
+
 		// 解析字段标签
 		tag := field.Tag.Get("orm")
 		if tag == "-" {
@@ -80,12 +161,40 @@ func RegisterModel[T any](tableName string) error {
 
 		// 记录主键
 		if fieldSchema.PrimaryKey {
-			schema.PrimaryKey = fieldSchema
+			if schema.PrimaryKey == nil {
+				schema.PrimaryKey = fieldSchema
+			}
+			schema.PrimaryKeys = append(schema.PrimaryKeys, fieldSchema)
+		}
+		if fieldSchema.Created {
+			schema.CreatedAt = fieldSchema
+		}
+		if fieldSchema.Updated {
+			schema.UpdatedAt = fieldSchema
+		}
+		if fieldSchema.SoftDelete {
+			schema.SoftDelete = fieldSchema
+		}
+		if fieldSchema.Version {
+			schema.Version = fieldSchema
 		}
 	}
 
-	schemaCache[uintptr(infMark.value)] = schema
-	return nil
+	return schema, nil
+}
+
+// tableNameOf 返回模型默认表名：优先 TableName() 方法，否则为类型名的 snake_case
+func tableNameOf(typ reflect.Type) (string, error) {
+	if typ.Kind() != reflect.Struct {
+		return "", fmt.Errorf("database: model %s is not a struct", typ)
+	}
+	if t, ok := reflect.New(typ).Interface().(tabler); ok {
+		return t.TableName(), nil
+	}
+	if typ.Name() == "" {
+		return "", fmt.Errorf("database: anonymous struct %s must be registered with RegisterModel", typ)
+	}
+	return struct2name(typ.String()), nil
 }
 
 // parseFieldSchema 解析字段标签
@@ -108,23 +217,87 @@ func parseFieldSchema(field reflect.StructField, tag string) *FieldSchema {
 			fieldSchema.PrimaryKey = true
 		case "auto":
 			fieldSchema.AutoIncr = true
+		case "sensitive":
+			fieldSchema.Sensitive = true
+		case "readonly":
+			fieldSchema.ReadOnly = true
+		case "insertonly":
+			fieldSchema.InsertOnly = true
+		case "default":
+			fieldSchema.Default = true
+		case "nullzero":
+			fieldSchema.NullZero = true
+		case "json", "jsonb":
+			fieldSchema.JSON = true
+		case "created":
+			fieldSchema.Created = true
+		case "updated":
+			fieldSchema.Updated = true
+		case "version":
+			fieldSchema.Version = true
+		case "softdelete":
+			fieldSchema.SoftDelete = true
 		}
 	}
 
 	return fieldSchema
 }
 
-// GetSchema 获取表元数据
-func GetSchema(model any) *TableSchema {
+// LookupSchema 获取表元数据，未注册的模型自动注册
+func LookupSchema(model any) (*TableSchema, error) {
 	typ := reflect.TypeOf(model)
+	if typ == nil {
+		return nil, errors.New("database: nil model")
+	}
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
 	}
+	if schema, ok := schemaCache.Load(typ); ok {
+		return schema.(*TableSchema), nil
+	}
+
+	tableName, err := tableNameOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := buildSchema(typ, tableName)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := storeSchema(typ, schema)
+	return actual, nil
+}
 
-	infMark := (*interfaceMark)(unsafe.Pointer(&typ))
-	//fmt.Println("load", typ.String(), infMark.value)
-	if schema, ok := schemaCache[uintptr(infMark.value)]; ok {
-		return schema
+// GetSchema 获取表元数据，失败时 panic
+func GetSchema(model any) *TableSchema {
+	schema, err := LookupSchema(model)
+	if err != nil {
+		panic(err)
 	}
-	panic("model not registered: " + typ.String())
+	return schema
+}
+
+// Ptr 返回 base 所指结构体中该字段的指针，可直接作为 Scan 目标
+func (f *FieldSchema) Ptr(base unsafe.Pointer) any {
+	return reflect.NewAt(f.GoType, unsafe.Add(base, f.Offset)).Interface()
+}
+
+// Value 返回 base 所指结构体中该字段的值
+func (f *FieldSchema) Value(base unsafe.Pointer) any {
+	return reflect.NewAt(f.GoType, unsafe.Add(base, f.Offset)).Elem().Interface()
+}
+
+// IsZero 判断 base 所指结构体中该字段是否为零值
+func (f *FieldSchema) IsZero(base unsafe.Pointer) bool {
+	return reflect.NewAt(f.GoType, unsafe.Add(base, f.Offset)).Elem().IsZero()
+}
+
+// Insertable 插入时是否写入该字段，非自增主键同样写入
+func (f *FieldSchema) Insertable() bool {
+	return !f.AutoIncr && !f.ReadOnly
+}
+
+// Updatable 更新时是否写入该字段
+func (f *FieldSchema) Updatable() bool {
+	return !f.PrimaryKey && !f.ReadOnly && !f.InsertOnly && !f.Created && !f.SoftDelete && !f.Version
 }
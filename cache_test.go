@@ -0,0 +1,87 @@
+package database
+
+import (
+	"sync"
+	"testing"
+)
+
+type UserProfile struct {
+	ID   int64  `orm:"id,pk,auto"`
+	Name string `orm:"name"`
+}
+
+type auditLog struct {
+	ID int64 `orm:"id,pk"`
+}
+
+func (auditLog) TableName() string { return "audit_logs" }
+
+type schemaUser struct {
+	ID int64 `orm:"id,pk"`
+}
+
+func (schemaUser) TableName() string { return "public.users" }
+
+func TestLookupSchema(t *testing.T) {
+	schema, err := LookupSchema(&UserProfile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.TableName != `"user_profile"` {
+		t.Fatalf("TableName = %s", schema.TableName)
+	}
+	if again, _ := LookupSchema(UserProfile{}); again != schema {
+		t.Fatal("schema should be cached per type")
+	}
+
+	schema, err = LookupSchema(auditLog{})
+	if err != nil || schema.TableName != `"audit_logs"` {
+		t.Fatalf("LookupSchema = %v, %v", schema, err)
+	}
+
+	schema, err = LookupSchema(schemaUser{})
+	if err != nil || schema.TableName != `"public"."users"` {
+		t.Fatalf("schema-qualified TableName = %v, %v", schema.TableName, err)
+	}
+	if err = RegisterModel[schemaUser](`"public"."users"`); err != nil {
+		t.Fatalf("registering the same table name: %v", err)
+	}
+	// 已自动注册为默认表名，再注册为其它表名不能被静默忽略
+	if err = RegisterModel[UserProfile]("profiles"); err == nil {
+		t.Fatal("expected error when re-registering with a different table name")
+	}
+
+	if _, err = LookupSchema(1); err == nil {
+		t.Fatal("expected error for non-struct model")
+	}
+	if _, err = LookupSchema(struct{ ID int }{}); err == nil {
+		t.Fatal("expected error for anonymous struct")
+	}
+	if _, err = LookupSchema(nil); err == nil {
+		t.Fatal("expected error for nil model")
+	}
+}
+
+func TestRegisterModel_Concurrent(t *testing.T) {
+	type item struct {
+		ID int64 `orm:"id,pk"`
+	}
+
+	var wg sync.WaitGroup
+	schemas := make([]*TableSchema, 16)
+	for i := range schemas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = RegisterModel[item]("items")
+			schemas[i] = GetSchema(&item{})
+		}()
+	}
+	wg.Wait()
+
+	for _, schema := range schemas {
+		if schema != schemas[0] || schema.TableName != `"items"` {
+			t.Fatalf("schema = %+v, want shared %+v", schema, schemas[0])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotFound 查询没有返回记录，同时满足 errors.Is(err, pgx.ErrNoRows)
+	ErrNotFound = errors.New("database: record not found")
+	// ErrConnection 无法建立或已丢失数据库连接
+	ErrConnection = errors.New("database: connection failed")
+	// ErrSerialization 序列化失败 (40001) 或死锁 (40P01)，可重试整个事务
+	ErrSerialization = errors.New("database: serialization failure")
+)
+
+// ErrUniqueViolation 唯一约束冲突 (23505)
+type ErrUniqueViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pgconn.PgError
+}
+
+func (e *ErrUniqueViolation) Error() string {
+	return fmt.Sprintf("database: unique violation on %s (%s)", e.Constraint, strings.Join(e.Columns, ", "))
+}
+
+func (e *ErrUniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrForeignKeyViolation 外键约束冲突 (23503)
+type ErrForeignKeyViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string
+	Err        *pgconn.PgError
+}
+
+func (e *ErrForeignKeyViolation) Error() string {
+	return fmt.Sprintf("database: foreign key violation on %s (%s)", e.Constraint, strings.Join(e.Columns, ", "))
+}
+
+func (e *ErrForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrCheckViolation 检查约束冲突 (23514)
+type ErrCheckViolation struct {
+	Table      string
+	Constraint string
+	Err        *pgconn.PgError
+}
+
+func (e *ErrCheckViolation) Error() string {
+	return fmt.Sprintf("database: check violation on %s", e.Constraint)
+}
+
+func (e *ErrCheckViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrStaleObject 乐观锁冲突：记录已被并发修改或删除，更新未影响任何行
+type ErrStaleObject struct {
+	Table   string
+	Version any // 更新时携带的旧版本号
+}
+
+func (e *ErrStaleObject) Error() string {
+	return fmt.Sprintf("database: stale object on %s (version %v)", e.Table, e.Version)
+}
+
+// keyColumnsRegex 从 PgError.Detail 中提取约束列，如 Key (a, b)=(1, 2) already exists.
+var keyColumnsRegex = regexp.MustCompile(`^Key \((.+?)\)=`)
+
+func keyColumns(detail string) []string {
+	match := keyColumnsRegex.FindStringSubmatch(detail)
+	if match == nil {
+		return nil
+	}
+	return strings.Split(match[1], ", ")
+}
+
+// classifyErr 将 pgx 返回的错误转换为本包的分类错误，原始错误仍可通过 errors.Is/As 取得
+func classifyErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			return &ErrUniqueViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Columns: keyColumns(pgErr.Detail), Err: pgErr}
+		case pgErr.Code == "23503":
+			return &ErrForeignKeyViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Columns: keyColumns(pgErr.Detail), Err: pgErr}
+		case pgErr.Code == "23514":
+			return &ErrCheckViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Err: pgErr}
+		case pgErr.Code == "40001" || pgErr.Code == "40P01":
+			return fmt.Errorf("%w: %w", ErrSerialization, err)
+		case strings.HasPrefix(pgErr.Code, "08"):
+			return fmt.Errorf("%w: %w", ErrConnection, err)
+		}
+		return err
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) && !errors.Is(err, ErrConnection) {
+		return fmt.Errorf("%w: %w", ErrConnection, err)
+	}
+	return err
+}
+
+// isExpectedErr 判断是否为调用方应自行处理、无需记录日志的业务错误
+func isExpectedErr(err error) bool {
+	var unique *ErrUniqueViolation
+	return errors.Is(err, ErrNotFound) || errors.As(err, &unique)
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyErr(t *testing.T) {
+	notFound := pool.queryErr(context.Background(), fmt.Errorf("scany: %w", pgx.ErrNoRows), "", "test", "SELECT 1", nil)
+	if !errors.Is(notFound, ErrNotFound) || !errors.Is(notFound, pgx.ErrNoRows) {
+		t.Errorf("expected ErrNotFound wrapping pgx.ErrNoRows, got %v", notFound)
+	}
+
+	unique := classifyErr(&pgconn.PgError{
+		Code:           "23505",
+		TableName:      "users",
+		ConstraintName: "users_org_id_email_key",
+		Detail:         "Key (org_id, email)=(1, a@b.c) already exists.",
+	})
+	var uv *ErrUniqueViolation
+	if !errors.As(unique, &uv) {
+		t.Fatalf("expected ErrUniqueViolation, got %T", unique)
+	}
+	if uv.Constraint != "users_org_id_email_key" || !reflect.DeepEqual(uv.Columns, []string{"org_id", "email"}) {
+		t.Errorf("unexpected unique violation: %+v", uv)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(unique, &pgErr) {
+		t.Error("original PgError should stay reachable")
+	}
+
+	var fk *ErrForeignKeyViolation
+	if !errors.As(classifyErr(&pgconn.PgError{Code: "23503", Detail: `Key (user_id)=(5) is not present in table "users".`}), &fk) ||
+		!reflect.DeepEqual(fk.Columns, []string{"user_id"}) {
+		t.Errorf("expected ErrForeignKeyViolation on user_id, got %+v", fk)
+	}
+	var check *ErrCheckViolation
+	if !errors.As(classifyErr(&pgconn.PgError{Code: "23514", ConstraintName: "age_positive"}), &check) {
+		t.Error("expected ErrCheckViolation")
+	}
+	if !errors.Is(classifyErr(&pgconn.PgError{Code: "40P01"}), ErrSerialization) {
+		t.Error("expected deadlock to classify as ErrSerialization")
+	}
+	if !errors.Is(classifyErr(&pgconn.PgError{Code: "08006"}), ErrConnection) {
+		t.Error("expected connection failure to classify as ErrConnection")
+	}
+	if !errors.Is(errClientNotInitialized, ErrConnection) {
+		t.Error("uninitialized client should report ErrConnection")
+	}
+}
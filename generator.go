@@ -5,12 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
 
-	"github.com/fexli/logger"
-
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -272,7 +271,7 @@ func (c *Client) ToStruct() {
 	tables, err := getTables(ctx)
 
 	if err != nil {
-		dbLog.Error(logger.WithContent("Error fetching tables", err))
+		c.log(ctx, slog.LevelError, "database: fetching tables failed", slog.Any(LogKeyError, err))
 		os.Exit(1)
 	}
 	var ts = map[string]string{}
@@ -285,23 +284,23 @@ func (c *Client) ToStruct() {
 
 		structCode, err := generateStructForTable(ctx, table)
 		if err != nil {
-			dbLog.Error(logger.WithContent("Error generating struct for table ", table, err))
+			c.log(ctx, slog.LevelError, "database: generating struct failed", slog.String(LogKeyTable, table[0]), slog.Any(LogKeyError, err))
 			continue
 		}
 
 		err = writeStructToFile(tableDeclPath, structCode)
 		if err != nil {
-			dbLog.Error(logger.WithContent("Error writing struct for table ", table, err))
+			c.log(ctx, slog.LevelError, "database: writing struct failed", slog.String(LogKeyTable, table[0]), slog.Any(LogKeyError, err))
 			continue
 		}
 
 		err = runGoImports(tableDeclPath)
 		if err != nil {
-			dbLog.Error(logger.WithContent("Error formatting file: ", table, err))
+			c.log(ctx, slog.LevelError, "database: formatting file failed", slog.String(LogKeyTable, table[0]), slog.Any(LogKeyError, err))
 			continue
 		}
 
-		dbLog.Notice(logger.WithContent("Struct for table", table, "written to file", tableDeclPath))
+		c.log(ctx, slog.LevelInfo, "database: struct written", slog.String(LogKeyTable, table[0]), slog.String("file", tableDeclPath))
 
 		//{
 		//	_, err := os.Stat(modelDeclPath)
@@ -322,14 +321,14 @@ func (c *Client) ToStruct() {
 		//
 		//	err = writeStructToFile(modelDeclPath, content)
 		//	if err != nil {
-		//		dbLog.Error(logger.WithContent("Error writing model for table ", table, err))
+		//		c.log(ctx, slog.LevelError, "database: writing model failed", slog.String(LogKeyTable, table[0]), slog.Any(LogKeyError, err))
 		//		continue
 		//	}
 		//	go runGoImports(modelDeclPath)
 		//
 		//	//err = writeStructToFile(fmt.Sprintf("db/model_%s_test.go", table), "package db\n\n")
 		//	//if err != nil {
-		//	//	dbLog.Error(logger.WithContent("Error writing model test for table ", table, err))
+		//	//	c.log(ctx, slog.LevelError, "database: writing model test failed", slog.String(LogKeyTable, table[0]), slog.Any(LogKeyError, err))
 		//	//	continue
 		//	//}
 		//}
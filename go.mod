@@ -5,7 +5,6 @@ go 1.23.4
 require (
 	github.com/Masterminds/squirrel v1.5.4
 	github.com/cridenour/go-postgis v1.0.1
-	github.com/fexli/logger v0.0.0-20230824164718-b56f05adf139
 	github.com/georgysavva/scany/v2 v2.1.3
 	github.com/jackc/pgx/v5 v5.7.2
 	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0
@@ -14,13 +13,10 @@ require (
 )
 
 require (
-	github.com/ahmetb/go-linq/v3 v3.2.0 // indirect
-	github.com/fatih/structs v1.1.0 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
-	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/crypto v0.32.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
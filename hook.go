@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryEvent 一次语句执行的信息
+type QueryEvent struct {
+	// Table 与 Action 来自 WithOperation 标记，未标记时为空
+	Table  string
+	Action string
+	SQL    string
+	// Args 为按 SetRedactionPolicy 脱敏后的参数，RedactDrop 且存在敏感参数时为 nil
+	Args []any
+	// args 原始参数，仅用于内部日志与 EXPLAIN
+	args []any
+	// replica 执行语句的副本，主库为 nil
+	replica *replica
+
+	StartTime time.Time
+	// 以下字段在 AfterQuery 时可用
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+}
+
+// QueryHook 语句执行钩子，在经由 Client 连接池执行的每条语句前后调用（包括事务内的语句），
+// 慢查询日志内部发起的 EXPLAIN 除外
+//
+// BeforeQuery 可返回新的 ctx（如携带追踪 span），该 ctx 会传给同一条语句的 AfterQuery；
+// 多个钩子时 BeforeQuery 按注册顺序调用，AfterQuery 按相反顺序调用
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// AddQueryHook 注册语句执行钩子，应在执行语句前调用
+func (c *Client) AddQueryHook(hooks ...QueryHook) *Client {
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], hooks...)
+	return c
+}
+
+// queryTracer 基于 pgx.QueryTracer 与 pgx.CopyFromTracer 调用 QueryHook 并以 Debug 级别记录每条语句
+type queryTracer struct {
+	c       *Client
+	replica *replica // 副本连接池的 tracer 指向该副本
+}
+
+type queryEventKey struct{}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if isInternalQuery(ctx) {
+		return ctx
+	}
+	if t.c.opts.QueryTracer != nil {
+		ctx = t.c.opts.QueryTracer.TraceQueryStart(ctx, conn, data)
+	}
+	return t.start(ctx, data.SQL, data.Args)
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	if isInternalQuery(ctx) {
+		return
+	}
+	t.end(ctx, data.CommandTag, data.Err)
+	if t.c.opts.QueryTracer != nil {
+		t.c.opts.QueryTracer.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+// TraceCopyFromStart 实现 pgx.CopyFromTracer，COPY 以 "COPY table (cols) FROM STDIN" 的形式上报，Args 为空
+func (t queryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	if tracer, ok := t.c.opts.QueryTracer.(pgx.CopyFromTracer); ok {
+		ctx = tracer.TraceCopyFromStart(ctx, conn, data)
+	}
+	columns := make([]string, len(data.ColumnNames))
+	for i, col := range data.ColumnNames {
+		columns[i] = pgx.Identifier{col}.Sanitize()
+	}
+	sql := "COPY " + data.TableName.Sanitize() + " (" + strings.Join(columns, ", ") + ") FROM STDIN"
+	return t.start(ctx, sql, nil)
+}
+
+// TraceCopyFromEnd 实现 pgx.CopyFromTracer
+func (t queryTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+	if tracer, ok := t.c.opts.QueryTracer.(pgx.CopyFromTracer); ok {
+		tracer.TraceCopyFromEnd(ctx, conn, data)
+	}
+}
+
+// start 创建语句事件并调用 BeforeQuery
+func (t queryTracer) start(ctx context.Context, sql string, args []any) context.Context {
+	hooks := t.c.hooks
+	if len(hooks) == 0 && t.c.opts.SlowQueryThreshold <= 0 && !t.c.getLogger().Enabled(ctx, slog.LevelDebug) {
+		return ctx
+	}
+
+	op := opFromContext(ctx)
+	event := &QueryEvent{
+		Table:     op.table,
+		Action:    op.action,
+		SQL:       sql,
+		Args:      redactArgs(op.table, sql, args),
+		args:      args,
+		replica:   t.replica,
+		StartTime: time.Now(),
+	}
+	for _, hook := range hooks {
+		ctx = hook.BeforeQuery(ctx, event)
+	}
+	return context.WithValue(ctx, queryEventKey{}, event)
+}
+
+// end 调用 AfterQuery 并记录语句与慢查询
+func (t queryTracer) end(ctx context.Context, tag pgconn.CommandTag, err error) {
+	event, ok := ctx.Value(queryEventKey{}).(*QueryEvent)
+	if !ok {
+		return
+	}
+	event.Duration = time.Since(event.StartTime)
+	event.RowsAffected = tag.RowsAffected()
+	event.Err = err
+
+	hooks := t.c.hooks
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctx, event)
+	}
+	t.c.logQuery(ctx, event)
+	if t.c.isSlowQuery(event) {
+		t.c.logSlowQuery(ctx, event)
+	}
+}
+
+// logQuery 以 Debug 级别记录语句
+func (c *Client) logQuery(ctx context.Context, event *QueryEvent) {
+	if !c.getLogger().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String(LogKeyTable, event.Table),
+		slog.String(LogKeyAction, event.Action),
+		slog.String(LogKeySQL, event.SQL),
+		slog.Any(LogKeyArgs, redactedArgs(event.Table, event.SQL, event.args)),
+		slog.Duration(LogKeyDuration, event.Duration),
+		slog.Int64(LogKeyRows, event.RowsAffected),
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any(LogKeyError, event.Err))
+	}
+	c.log(ctx, slog.LevelDebug, "database: query", attrs...)
+}
+
+// PoolStats 连接池状态快照
+type PoolStats struct {
+	AcquiredConns     int32
+	IdleConns         int32
+	TotalConns        int32
+	ConstructingConns int32
+	MaxConns          int32
+
+	// 以下为累计值
+	AcquireCount         int64
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	// AcquireDuration 获取连接的累计耗时（含等待空闲连接的时间）
+	AcquireDuration time.Duration
+}
+
+// PoolStats 返回连接池状态
+func (c *Client) PoolStats() PoolStats {
+	if c == nil || c.Client == nil {
+		return PoolStats{}
+	}
+	s := c.Client.Stat()
+	return PoolStats{
+		AcquiredConns:        s.AcquiredConns(),
+		IdleConns:            s.IdleConns(),
+		TotalConns:           s.TotalConns(),
+		ConstructingConns:    s.ConstructingConns(),
+		MaxConns:             s.MaxConns(),
+		AcquireCount:         s.AcquireCount(),
+		EmptyAcquireCount:    s.EmptyAcquireCount(),
+		CanceledAcquireCount: s.CanceledAcquireCount(),
+		AcquireDuration:      s.AcquireDuration(),
+	}
+}
+
+// ReportPoolStats 每隔 interval 将连接池状态传给 fn（如写入 Prometheus gauge），直到 ctx 结束
+func (c *Client) ReportPoolStats(ctx context.Context, interval time.Duration, fn func(PoolStats)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(c.PoolStats())
+			}
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type recordHook struct {
+	name   string
+	calls  *[]string
+	events []*QueryEvent
+}
+
+type hookCtxKey struct{}
+
+func (h *recordHook) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, hookCtxKey{}, h.name)
+}
+
+func (h *recordHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	*h.calls = append(*h.calls, "after "+h.name+" "+ctx.Value(hookCtxKey{}).(string))
+	h.events = append(h.events, event)
+}
+
+func TestQueryTracerHooks(t *testing.T) {
+	var calls []string
+	first := &recordHook{name: "a", calls: &calls}
+	second := &recordHook{name: "b", calls: &calls}
+	c := (&Client{}).SetLogger(NopLogger).AddQueryHook(first, second)
+	tracer := queryTracer{c: c}
+
+	ctx := WithOperation(context.Background(), `"users"`, "orm.Update")
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "UPDATE users SET age = $1", Args: []any{18}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 3")})
+
+	want := []string{"before a", "before b", "after b b", "after a b"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+
+	event := first.events[0]
+	if event.Table != "users" || event.Action != "orm.Update" || event.RowsAffected != 3 || event.SQL == "" || event.Duration <= 0 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestQueryTracerCopyFrom(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "a", calls: &calls}
+	tracer := queryTracer{c: (&Client{}).SetLogger(NopLogger).AddQueryHook(hook)}
+
+	ctx := WithOperation(context.Background(), `"public"."users"`, "orm.CopyFrom")
+	ctx = tracer.TraceCopyFromStart(ctx, nil, pgx.TraceCopyFromStartData{
+		TableName:   tableIdentifier(`"public"."users"`),
+		ColumnNames: []string{"name", "age"},
+	})
+	tracer.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 2")})
+
+	if len(hook.events) != 1 {
+		t.Fatalf("calls = %v", calls)
+	}
+	event := hook.events[0]
+	if event.SQL != `COPY "public"."users" ("name", "age") FROM STDIN` || event.Table != "public.users" || event.RowsAffected != 2 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
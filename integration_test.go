@@ -0,0 +1,173 @@
+//go:build integration
+
+// 集成测试需要可写的 PostgreSQL：DATABASE_URL=postgres://... go test -tags integration ./...
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// newIntegrationClient 连接 DATABASE_URL 并创建只含 id 列的测试表，未设置时跳过
+func newIntegrationClient(t *testing.T) (*Client, string) {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	opts := DefaultOptions(url)
+	opts.SkipPreload = true
+	c, err := NewClientWithConfig(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool == c {
+		SetDefault(nil)
+	}
+
+	table := fmt.Sprintf("it_tx_%d", time.Now().UnixNano())
+	if _, err = c.Exec(context.Background(), "CREATE TABLE "+table+" (id int PRIMARY KEY)"); err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = c.Exec(context.Background(), "DROP TABLE IF EXISTS "+table)
+		c.Close()
+	})
+	return c, table
+}
+
+func countRows(t *testing.T, c *Client, table string) int {
+	t.Helper()
+	var n int
+	if err := c.QueryRow(context.Background(), "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestIntegrationSavepoint(t *testing.T) {
+	c, table := newIntegrationClient(t)
+	ctx := context.Background()
+	errInner := errors.New("inner")
+
+	err := c.Tx(ctx, TxOptions{}, func(tx *Tx) error {
+		if _, err := tx.Exec(tx.Context(), "INSERT INTO "+table+" VALUES (1)"); err != nil {
+			return err
+		}
+		// 嵌套事务失败只回滚到 SAVEPOINT
+		err := c.Tx(tx.Context(), TxOptions{}, func(inner *Tx) error {
+			if _, err := inner.Exec(inner.Context(), "INSERT INTO "+table+" VALUES (2)"); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			return fmt.Errorf("nested tx err = %v", err)
+		}
+		// 嵌套事务成功时随外层提交
+		return tx.Tx(tx.Context(), TxOptions{}, func(inner *Tx) error {
+			_, err := inner.Exec(inner.Context(), "INSERT INTO "+table+" VALUES (3)")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	rows, err := c.Query(ctx, "SELECT id FROM "+table+" ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, err = pgx.CollectRows(rows, pgx.RowTo[int]); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[1 3]" {
+		t.Fatalf("ids = %v, want [1 3]", ids)
+	}
+
+	// 外层回滚时嵌套事务的写入一并回滚
+	_ = c.Tx(ctx, TxOptions{}, func(tx *Tx) error {
+		_ = c.Tx(tx.Context(), TxOptions{}, func(inner *Tx) error {
+			_, err := inner.Exec(inner.Context(), "INSERT INTO "+table+" VALUES (4)")
+			return err
+		})
+		return errInner
+	})
+	if n := countRows(t, c, table); n != 2 {
+		t.Fatalf("rows = %d, want 2 after outer rollback", n)
+	}
+}
+
+func TestIntegrationSerializationRetry(t *testing.T) {
+	c, table := newIntegrationClient(t)
+	ctx := context.Background()
+
+	var attempts atomic.Int32
+	err := c.Tx(ctx, TxOptions{IsoLevel: pgx.Serializable, MaxRetries: 3}, func(tx *Tx) error {
+		attempt := attempts.Add(1)
+		var n int
+		if err := tx.QueryRow(tx.Context(), "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+			return err
+		}
+		if attempt == 1 {
+			// 并发的可串行化事务读取同一谓词并先行提交，使本事务在写入或提交时失败 (40001)
+			err := c.Tx(ctx, TxOptions{IsoLevel: pgx.Serializable}, func(other *Tx) error {
+				var m int
+				if err := other.QueryRow(other.Context(), "SELECT count(*) FROM "+table).Scan(&m); err != nil {
+					return err
+				}
+				_, err := other.Exec(other.Context(), fmt.Sprintf("INSERT INTO %s VALUES (%d)", table, 100+m))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(tx.Context(), fmt.Sprintf("INSERT INTO %s VALUES (%d)", table, n+1))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Fatalf("attempts = %d, want 2", n)
+	}
+	if n := countRows(t, c, table); n != 2 {
+		t.Fatalf("rows = %d, want 2", n)
+	}
+
+	// 不允许重试时返回 ErrSerialization
+	attempts.Store(0)
+	err = c.Tx(ctx, TxOptions{IsoLevel: pgx.Serializable}, func(tx *Tx) error {
+		attempts.Add(1)
+		var n int
+		if err := tx.QueryRow(tx.Context(), "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+			return err
+		}
+		err := c.Tx(ctx, TxOptions{IsoLevel: pgx.Serializable}, func(other *Tx) error {
+			var m int
+			if err := other.QueryRow(other.Context(), "SELECT count(*) FROM "+table).Scan(&m); err != nil {
+				return err
+			}
+			_, err := other.Exec(other.Context(), fmt.Sprintf("INSERT INTO %s VALUES (%d)", table, 200+m))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(tx.Context(), fmt.Sprintf("INSERT INTO %s VALUES (%d)", table, 300+n))
+		return err
+	})
+	if !errors.Is(err, ErrSerialization) || attempts.Load() != 1 {
+		t.Fatalf("err = %v, attempts = %d; want ErrSerialization without retry", err, attempts.Load())
+	}
+}
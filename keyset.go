@@ -0,0 +1,385 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/skadiD/database/scan/custom_row2struct"
+)
+
+// ErrInvalidCursor 游标格式错误、签名不匹配或与排序列不一致
+var ErrInvalidCursor = errors.New("database: invalid cursor")
+
+// Keyset 键集分页参数
+type Keyset struct {
+	// OrderBy 排序列，如 []string{"created_at DESC", "id"}，最后一列应为唯一键（通常为主键），各列不能为 NULL
+	OrderBy []string
+	// Size 每页条数，为 0 时使用 MinPageElements
+	Size uint64
+	// Cursor 上一次返回的 Next 或 Prev，为空时从第一页开始
+	Cursor string
+}
+
+// KeysetPage 键集分页结果
+type KeysetPage[T any] struct {
+	Items []T
+	// Next 下一页游标，没有更多记录时为空
+	Next string
+	// Prev 上一页游标，位于第一页时为空
+	Prev string
+}
+
+var (
+	cursorKeyMu sync.RWMutex
+	cursorKey   = func() []byte {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		return key
+	}()
+)
+
+// SetCursorKey 设置游标的签名密钥
+//
+// 默认使用进程启动时生成的随机密钥，多实例部署或需要跨重启使用游标时应设置为固定值
+func SetCursorKey(key []byte) {
+	cursorKeyMu.Lock()
+	defer cursorKeyMu.Unlock()
+	cursorKey = slices.Clone(key)
+}
+
+// SelectKeyset 键集分页查询
+func SelectKeyset[T any](db pgxscan.Querier, sb sq.SelectBuilder, ks Keyset) (KeysetPage[T], error) {
+	return SelectKeysetContext[T](context.Background(), db, sb, ks)
+}
+
+// SelectKeysetContext 键集分页查询
+//
+// sb 不应设置排序与分页，将按 ks.OrderBy 追加排序、游标条件与 LIMIT；
+// 游标中的排序值从 T 中与排序列同名的字段读取（db 标签、orm 标签或字段名）
+func SelectKeysetContext[T any](ctx context.Context, db pgxscan.Querier, sb sq.SelectBuilder, ks Keyset) (KeysetPage[T], error) {
+	ctx = withRead(WithOperation(ctx, "", "database.SelectKeyset"))
+	var page KeysetPage[T]
+	cols, err := parseKeysetOrder(ks.OrderBy)
+	if err == nil {
+		err = keysetFields(reflect.TypeFor[T](), cols)
+	}
+	if err != nil {
+		return page, clientOf(db).buildErr(ctx, err, "", "database.SelectKeyset")
+	}
+	size := ks.Size
+	if size == 0 {
+		size = MinPageElements
+	}
+
+	var cur *cursor
+	if ks.Cursor != "" {
+		if cur, err = decodeCursor(ks.Cursor, ks.OrderBy); err != nil {
+			return page, err
+		}
+		sb = sb.Where(keysetCond(cols, cur.values, cur.Prev))
+	}
+	backward := cur != nil && cur.Prev
+	items, err := SelectContext[T](ctx, db, sb.OrderBy(keysetOrderBy(cols, backward)...).Limit(size+1))
+	if err != nil {
+		return page, err
+	}
+
+	more := uint64(len(items)) > size
+	if more {
+		items = items[:size]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	page.Items = items
+	if len(items) == 0 {
+		return page, nil
+	}
+	// 向后翻页时当前页之后必有记录；向前翻页时当前页之前必有记录
+	if more || backward {
+		if page.Next, err = encodeCursor(ks.OrderBy, cols, &items[len(items)-1], false); err != nil {
+			return page, err
+		}
+	}
+	if more && backward || cur != nil && !backward {
+		if page.Prev, err = encodeCursor(ks.OrderBy, cols, &items[0], true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// keysetColumn 排序列
+type keysetColumn struct {
+	name  string
+	desc  bool
+	index []int // T 中对应字段的路径
+}
+
+// parseKeysetOrder 解析形如 "col"、"col ASC"、"col DESC" 的排序列
+func parseKeysetOrder(orderBy []string) ([]keysetColumn, error) {
+	if len(orderBy) == 0 {
+		return nil, errors.New("keyset: order by is required")
+	}
+	cols := make([]keysetColumn, len(orderBy))
+	for i, clause := range orderBy {
+		parts := strings.Fields(clause)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("keyset: unsupported order clause %q", clause)
+		}
+		cols[i].name = parts[0]
+		if len(parts) == 2 {
+			switch strings.ToUpper(parts[1]) {
+			case "ASC":
+			case "DESC":
+				cols[i].desc = true
+			default:
+				return nil, fmt.Errorf("keyset: unsupported order clause %q", clause)
+			}
+		}
+	}
+	return cols, nil
+}
+
+// keysetOrderBy 排序子句，backward 时反转方向
+func keysetOrderBy(cols []keysetColumn, backward bool) []string {
+	clauses := make([]string, len(cols))
+	for i, col := range cols {
+		if col.desc != backward {
+			clauses[i] = col.name + " DESC"
+		} else {
+			clauses[i] = col.name + " ASC"
+		}
+	}
+	return clauses
+}
+
+// keysetCond 位于 values 之后（before 时为之前）的条件
+//
+// 各列方向一致时使用行值比较 (a, b) > (?, ?) 以便利用联合索引，
+// 方向混合时展开为 a > ? OR (a = ? AND b < ?) 的形式
+func keysetCond(cols []keysetColumn, values []any, before bool) sq.Sqlizer {
+	op := func(col keysetColumn) string {
+		if col.desc != before {
+			return " < "
+		}
+		return " > "
+	}
+
+	uniform := true
+	for _, col := range cols[1:] {
+		uniform = uniform && col.desc == cols[0].desc
+	}
+	if uniform {
+		if len(cols) == 1 {
+			return sq.Expr(cols[0].name+op(cols[0])+"?", values[0])
+		}
+		names := make([]string, len(cols))
+		for i, col := range cols {
+			names[i] = col.name
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+		return sq.Expr("("+strings.Join(names, ", ")+")"+op(cols[0])+"("+placeholders+")", values...)
+	}
+
+	or := make(sq.Or, len(cols))
+	for i, col := range cols {
+		and := make(sq.And, 0, i+1)
+		for j := range i {
+			and = append(and, sq.Expr(cols[j].name+" = ?", values[j]))
+		}
+		or[i] = append(and, sq.Expr(col.name+op(col)+"?", values[i]))
+	}
+	return or
+}
+
+// keysetFields 查找排序列在 t 中对应的字段
+func keysetFields(t reflect.Type, cols []keysetColumn) error {
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("keyset: %s is not a struct", t)
+	}
+	for i := range cols {
+		// 去除表前缀与引号，如 "user".id
+		name := normalizeIdent(cols[i].name)
+		if cols[i].index = fieldIndexByColumn(t, name); cols[i].index == nil {
+			return fmt.Errorf("keyset: no field of %s matches column %s", t, name)
+		}
+	}
+	return nil
+}
+
+// fieldIndexByColumn 与行扫描一致按 db 标签、orm 标签或字段名查找列对应的字段
+func fieldIndexByColumn(t reflect.Type, col string) []int {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if index := fieldIndexByColumn(sf.Type, col); index != nil {
+				return append([]int{i}, index...)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := sf.Tag.Lookup("db")
+		fuzzy := false
+		if ok {
+			name, _, _ = strings.Cut(name, ",")
+		} else {
+			name, fuzzy = custom_row2struct.OrmColumnName(sf)
+		}
+		if name == "-" {
+			continue
+		}
+		if strings.EqualFold(name, col) ||
+			fuzzy && strings.EqualFold(strings.ReplaceAll(name, "_", ""), strings.ReplaceAll(col, "_", "")) {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+// cursor 游标内容，Order 用于拒绝在其它排序下使用的游标
+type cursor struct {
+	Order  string        `json:"o"`
+	Prev   bool          `json:"p,omitempty"`
+	Values []cursorValue `json:"v"`
+
+	values []any
+}
+
+// cursorValue 带类型的排序值，保证解码后的参数类型与原值一致
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// encodeCursor 以 item 的排序值生成签名游标：base64(payload).base64(hmac)
+func encodeCursor[T any](orderBy []string, cols []keysetColumn, item *T, prev bool) (string, error) {
+	v := reflect.ValueOf(item).Elem()
+	c := cursor{Order: strings.Join(orderBy, ","), Prev: prev, Values: make([]cursorValue, len(cols))}
+	for i, col := range cols {
+		value, err := encodeCursorValue(v.FieldByIndex(col.index).Interface())
+		if err != nil {
+			return "", fmt.Errorf("keyset: column %s: %w", col.name, err)
+		}
+		c.Values[i] = value
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signCursor(payload)), nil
+}
+
+// decodeCursor 校验签名并解码游标
+func decodeCursor(s string, orderBy []string) (*cursor, error) {
+	enc := base64.RawURLEncoding
+	payloadPart, sigPart, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err = json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Order != strings.Join(orderBy, ",") || len(c.Values) != len(orderBy) {
+		return nil, fmt.Errorf("%w: order mismatch", ErrInvalidCursor)
+	}
+	c.values = make([]any, len(c.Values))
+	for i, v := range c.Values {
+		if c.values[i], err = v.decode(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+	}
+	return &c, nil
+}
+
+func signCursor(payload []byte) []byte {
+	cursorKeyMu.RLock()
+	defer cursorKeyMu.RUnlock()
+	mac := hmac.New(sha256.New, cursorKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func encodeCursorValue(v any) (cursorValue, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return cursorValue{}, err
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer {
+		return cursorValue{}, errors.New("NULL value is not supported")
+	}
+
+	switch x := rv.Interface().(type) {
+	case time.Time:
+		return cursorValue{"t", x.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return cursorValue{"x", base64.StdEncoding.EncodeToString(x)}, nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{"i", strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{"u", strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{"f", strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return cursorValue{"s", rv.String()}, nil
+	case reflect.Bool:
+		return cursorValue{"b", strconv.FormatBool(rv.Bool())}, nil
+	}
+	return cursorValue{}, fmt.Errorf("unsupported type %T", v)
+}
+
+func (v cursorValue) decode() (any, error) {
+	switch v.Type {
+	case "t":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "x":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "i":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "u":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(v.Value, 64)
+	case "s":
+		return v.Value, nil
+	case "b":
+		return strconv.ParseBool(v.Value)
+	}
+	return nil, fmt.Errorf("unknown value type %q", v.Type)
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type keysetItem struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Score     float64
+	Name      string `orm:"title"`
+}
+
+func TestKeysetCond(t *testing.T) {
+	cases := []struct {
+		order  []string
+		before bool
+		want   string
+	}{
+		{[]string{"id"}, false, `id > $1`},
+		{[]string{"id DESC"}, false, `id < $1`},
+		{[]string{"created_at", `"t".id`}, false, `(created_at, "t".id) > ($1, $2)`},
+		{[]string{"created_at desc", "id DESC"}, false, `(created_at, id) < ($1, $2)`},
+		{[]string{"created_at DESC", "id DESC"}, true, `(created_at, id) > ($1, $2)`},
+		{[]string{"score DESC", "id"}, false, `((score < $1) OR (score = $2 AND id > $3))`},
+		{[]string{"score DESC", "name", "id"}, true,
+			`((score > $1) OR (score = $2 AND name < $3) OR (score = $4 AND name = $5 AND id < $6))`},
+	}
+
+	for _, c := range cases {
+		cols, err := parseKeysetOrder(c.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make([]any, len(cols))
+		sql, args, err := psql.Select("*").From("t").Where(keysetCond(cols, values, c.before)).ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimPrefix(sql, "SELECT * FROM t WHERE "); got != c.want {
+			t.Errorf("%v before=%v: got %s, want %s", c.order, c.before, got, c.want)
+		}
+		if n := strings.Count(sql, "$"); n != len(args) {
+			t.Errorf("%v: %d placeholders, %d args", c.order, n, len(args))
+		}
+	}
+
+	for _, order := range [][]string{nil, {"id NULLS LAST"}, {"a b c"}} {
+		if _, err := parseKeysetOrder(order); err == nil {
+			t.Errorf("parseKeysetOrder(%q) expected error", order)
+		}
+	}
+	cols, _ := parseKeysetOrder([]string{"score DESC", "id"})
+	if got := keysetOrderBy(cols, true); !reflect.DeepEqual(got, []string{"score ASC", "id DESC"}) {
+		t.Errorf("backward order = %v", got)
+	}
+}
+
+func TestKeysetCursor(t *testing.T) {
+	order := []string{"created_at DESC", `"t".id`, "score", "title"}
+	cols, _ := parseKeysetOrder(order)
+	if err := keysetFields(reflect.TypeFor[keysetItem](), cols); err != nil {
+		t.Fatal(err)
+	}
+	item := keysetItem{ID: 42, CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123, time.UTC), Score: 1.5, Name: "a.b"}
+
+	token, err := encodeCursor(order, cols, &item, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := decodeCursor(token, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{item.CreatedAt, int64(42), 1.5, "a.b"}
+	if !c.Prev || !reflect.DeepEqual(c.values, want) {
+		t.Fatalf("decoded = %v %#v", c.Prev, c.values)
+	}
+
+	// 篡改内容、交换签名或更换排序均应拒绝
+	payload, sig, _ := strings.Cut(token, ".")
+	other, _ := encodeCursor(order, cols, &keysetItem{ID: 1, CreatedAt: item.CreatedAt}, false)
+	_, otherSig, _ := strings.Cut(other, ".")
+	for _, bad := range []string{"", "abc", payload, payload + "x." + sig, payload + "." + otherSig} {
+		if _, err = decodeCursor(bad, order); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) err = %v", bad, err)
+		}
+	}
+	if _, err = decodeCursor(token, []string{"created_at", "id"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("order mismatch err = %v", err)
+	}
+
+	defer SetCursorKey(cursorKey)
+	SetCursorKey([]byte("another key"))
+	if _, err = decodeCursor(token, order); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("key change err = %v", err)
+	}
+
+	if _, err = encodeCursorValue((*int)(nil)); err == nil {
+		t.Error("expected error for NULL value")
+	}
+	if err = keysetFields(reflect.TypeFor[keysetItem](), []keysetColumn{{name: "missing"}}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestSelectKeyset(t *testing.T) {
+	ks := Keyset{OrderBy: []string{"id"}, Size: 5}
+	if _, err := SelectKeyset[keysetItem](&Client{}, sq.Select("*").From("t"), ks); !errors.Is(err, ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	ks.Cursor = "bogus"
+	if _, err := SelectKeysetContext[keysetItem](context.Background(), &Client{}, sq.Select("*").From("t"), ks); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// Logger 日志接口，Client 的全部日志都通过它输出
+type Logger interface {
+	// Enabled 判断是否输出该级别的日志，用于跳过昂贵的字段构建
+	Enabled(ctx context.Context, level slog.Level) bool
+	Log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+// 结构化日志字段名
+const (
+	LogKeyTable    = "table"
+	LogKeyAction   = "action"
+	LogKeySQL      = "sql"
+	LogKeyArgs     = "args"
+	LogKeyDuration = "duration"
+	LogKeyRows     = "rows"
+	LogKeyError    = "error"
+	LogKeyStack    = "stack"
+)
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger 使用 slog.Logger 输出日志，l 为 nil 时使用 slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) logger() *slog.Logger {
+	if s.l == nil {
+		return slog.Default()
+	}
+	return s.l
+}
+
+func (s slogLogger) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.logger().Enabled(ctx, level)
+}
+
+func (s slogLogger) Log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	s.logger().LogAttrs(ctx, level, msg, attrs...)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Enabled(context.Context, slog.Level) bool              { return false }
+func (nopLogger) Log(context.Context, slog.Level, string, ...slog.Attr) {}
+
+// NopLogger 丢弃全部日志
+var NopLogger Logger = nopLogger{}
+
+var defaultLogger = NewSlogLogger(nil)
+
+// SetDefaultLogger 设置未指定 Logger 的 Client 及未经 Client 执行的语句所使用的 Logger
+func SetDefaultLogger(l Logger) {
+	if l == nil {
+		l = NopLogger
+	}
+	defaultLogger = l
+}
+
+// SetLogger 设置 Client 的 Logger，应在执行语句前调用
+func (c *Client) SetLogger(l Logger) *Client {
+	c.logger = l
+	return c
+}
+
+func (c *Client) getLogger() Logger {
+	if c == nil || c.logger == nil {
+		return defaultLogger
+	}
+	return c.logger
+}
+
+func (c *Client) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	l := c.getLogger()
+	if l.Enabled(ctx, level) {
+		l.Log(ctx, level, msg, attrs...)
+	}
+}
+
+// clientOf 返回 db 对应的 Client，db 不是 *Client 时返回 nil（使用默认 Logger）
+func clientOf(db pgxscan.Querier) *Client {
+	c, _ := db.(*Client)
+	return c
+}
+
+// logErr 记录失败的操作，调用方应自行处理的业务错误（如无记录、唯一约束冲突）不记录
+func (c *Client) logErr(ctx context.Context, err error, table, action string, attrs ...slog.Attr) {
+	if err == nil || isExpectedErr(err) {
+		return
+	}
+	l := c.getLogger()
+	if !l.Enabled(ctx, slog.LevelWarn) {
+		return
+	}
+	// 以外层标记的操作为准，如 orm.Update 调用 Client.Update 时记录 orm.Update
+	if op := opFromContext(ctx); op.action != "" {
+		action = op.action
+		if table == "" {
+			table = op.table
+		}
+	}
+	attrs = append(attrs,
+		slog.String(LogKeyTable, table),
+		slog.String(LogKeyAction, action),
+		slog.Any(LogKeyError, err),
+	)
+	if c != nil && c.opts.LogStackTrace {
+		attrs = append(attrs, slog.String(LogKeyStack, string(debug.Stack())))
+	}
+	l.Log(ctx, slog.LevelWarn, "database: "+action+" failed", attrs...)
+}
+
+// opKey 上下文中当前操作的表名与动作
+type opKey struct{}
+
+type opInfo struct {
+	table  string
+	action string
+}
+
+// WithOperation 在 ctx 中标记当前操作的表名与动作，用于日志与查询追踪
+//
+// ctx 中已有标记时保留外层标记，使 orm 等上层调用的信息不被内部实现覆盖
+func WithOperation(ctx context.Context, table, action string) context.Context {
+	if _, ok := ctx.Value(opKey{}).(opInfo); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, opKey{}, opInfo{table: strings.ReplaceAll(table, `"`, ""), action: action})
+}
+
+func opFromContext(ctx context.Context) opInfo {
+	info, _ := ctx.Value(opKey{}).(opInfo)
+	return info
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestClientLogErr(t *testing.T) {
+	var buf bytes.Buffer
+	c := (&Client{}).SetLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	ctx := WithOperation(context.Background(), `"users"`, "orm.Update")
+	err := c.queryErr(ctx, errors.New("boom"), "", "database.Update", "UPDATE users SET name = $1", []any{"x"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{
+		"level":      "WARN",
+		LogKeyTable:  "users",
+		LogKeyAction: "orm.Update",
+		LogKeySQL:    "UPDATE users SET name = $1",
+		"msg":        "database: orm.Update failed",
+	} {
+		if entry[key] != want {
+			t.Errorf("%s = %v, want %q", key, entry[key], want)
+		}
+	}
+	if _, ok := entry[LogKeyStack]; ok {
+		t.Error("stack trace should be disabled by default")
+	}
+
+	buf.Reset()
+	_ = c.queryErr(ctx, pgx.ErrNoRows, "", "database.Get", "SELECT 1", nil)
+	if buf.Len() != 0 {
+		t.Errorf("not found should not be logged, got %s", buf.String())
+	}
+}
+
+func TestNopLogger(t *testing.T) {
+	c := (&Client{}).SetLogger(NopLogger)
+	if c.getLogger().Enabled(context.Background(), slog.LevelError) {
+		t.Error("NopLogger should be disabled")
+	}
+}
@@ -0,0 +1,163 @@
+package orm
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/skadiD/database"
+)
+
+// GroupBy 添加分组
+func (s *Selector[T]) GroupBy(cols ...string) *Selector[T] {
+	s.groupBy = append(s.groupBy, cols...)
+	return s
+}
+
+// Having 添加分组条件
+func (s *Selector[T]) Having(cond sq.Sqlizer) *Selector[T] {
+	s.having = append(s.having, cond)
+	return s
+}
+
+// Count 统计记录数，设置 GroupBy 时统计分组数
+func (s *Selector[T]) Count() (int64, error) {
+	return s.CountContext(s.ctx)
+}
+
+// CountContext 使用指定上下文统计记录数
+func (s *Selector[T]) CountContext(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.value(ctx, "orm.Count", s.countSql, &n)
+	return n, err
+}
+
+// countSql 统计语句，分组时对分组结果计数
+func (s *Selector[T]) countSql() sq.SelectBuilder {
+	if len(s.groupBy) == 0 {
+		return s.baseSql([]string{"COUNT(*)"})
+	}
+	inner := s.baseSql([]string{"1"}).GroupBy(s.groupBy...)
+	if len(s.having) > 0 {
+		inner = inner.Having(sq.And(s.having))
+	}
+	return psql.Select("COUNT(*)").FromSelect(inner.PlaceholderFormat(sq.Question), "t")
+}
+
+// Exists 是否存在满足条件的记录
+func (s *Selector[T]) Exists() (bool, error) {
+	return s.ExistsContext(s.ctx)
+}
+
+// ExistsContext 使用指定上下文判断是否存在满足条件的记录
+func (s *Selector[T]) ExistsContext(ctx context.Context) (bool, error) {
+	var ok bool
+	err := s.value(ctx, "orm.Exists", s.existsSql, &ok)
+	return ok, err
+}
+
+// existsSql 存在性查询
+func (s *Selector[T]) existsSql() sq.SelectBuilder {
+	inner := s.sqlWith([]string{"1"}).PlaceholderFormat(sq.Question)
+	return psql.Select().Column(sq.Expr("EXISTS (?)", inner))
+}
+
+// Avg 平均值，无记录时为 0
+//
+// Avg 与 Sum/Min/Max 作用于满足连接与条件的全部记录，忽略分组，分组聚合请配合 Select 与 Scan 使用
+func (s *Selector[T]) Avg(col string) (float64, error) {
+	return s.AvgContext(s.ctx, col)
+}
+
+// AvgContext 使用指定上下文求平均值，结果以 float8 返回
+func (s *Selector[T]) AvgContext(ctx context.Context, col string) (float64, error) {
+	var v float64
+	err := s.value(ctx, "orm.Avg", func() sq.SelectBuilder {
+		return s.baseSql([]string{"COALESCE(AVG(" + col + "), 0)::float8"})
+	}, &v)
+	return v, err
+}
+
+// Sum 求和并扫描到 V，无记录时为零值；整数列的和为 numeric，可扫描到 int64 而不丢失精度
+func Sum[V, T any](s *Selector[T], col string) (V, error) {
+	return aggregate[V](s.ctx, s, "SUM", col)
+}
+
+// SumContext 使用指定上下文执行 Sum
+func SumContext[V, T any](ctx context.Context, s *Selector[T], col string) (V, error) {
+	return aggregate[V](ctx, s, "SUM", col)
+}
+
+// Min 最小值，可用于时间、文本等任意可比较的列，无记录时为零值
+func Min[V, T any](s *Selector[T], col string) (V, error) {
+	return aggregate[V](s.ctx, s, "MIN", col)
+}
+
+// MinContext 使用指定上下文执行 Min
+func MinContext[V, T any](ctx context.Context, s *Selector[T], col string) (V, error) {
+	return aggregate[V](ctx, s, "MIN", col)
+}
+
+// Max 最大值，可用于时间、文本等任意可比较的列，无记录时为零值
+func Max[V, T any](s *Selector[T], col string) (V, error) {
+	return aggregate[V](s.ctx, s, "MAX", col)
+}
+
+// MaxContext 使用指定上下文执行 Max
+func MaxContext[V, T any](ctx context.Context, s *Selector[T], col string) (V, error) {
+	return aggregate[V](ctx, s, "MAX", col)
+}
+
+// aggregate 执行单列聚合，结果为 NULL 时返回零值
+func aggregate[V, T any](ctx context.Context, s *Selector[T], fn, col string) (V, error) {
+	var v *V
+	query := func() sq.SelectBuilder { return s.aggregateSql(fn, col) }
+	if err := s.value(ctx, "orm."+fn, query, &v); err != nil || v == nil {
+		var zero V
+		return zero, err
+	}
+	return *v, nil
+}
+
+// aggregateSql 聚合语句，不做类型转换
+func (s *Selector[T]) aggregateSql(fn, col string) sq.SelectBuilder {
+	return s.baseSql([]string{fn + "(" + col + ")"})
+}
+
+// value 执行单值查询并扫描到 dest，query 在 s.err 为空时才构造
+func (s *Selector[T]) value(ctx context.Context, action string, query func() sq.SelectBuilder, dest any) error {
+	if s.err != nil {
+		return s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, action)
+	_, err := s.client.ScanContext(ctx, query(), func(rows pgx.Rows) error {
+		return rows.Scan(dest)
+	})
+	return err
+}
+
+// Pluck 查询单列并返回 []V，保留排序与分页
+func Pluck[V, T any](s *Selector[T], col string) ([]V, error) {
+	return PluckContext[V](s.ctx, s, col)
+}
+
+// PluckContext 使用指定上下文执行 Pluck
+func PluckContext[V, T any](ctx context.Context, s *Selector[T], col string) ([]V, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.Pluck")
+	var values []V
+	_, err := s.client.ScanContext(ctx, s.sqlWith([]string{col}), func(rows pgx.Rows) error {
+		var v V
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -1,7 +1,7 @@
 package orm
 
 import (
-	"reflect"
+	"context"
 	"unsafe"
 
 	"github.com/skadiD/database"
@@ -9,42 +9,61 @@ import (
 
 // Inserter 插入操作结构体
 type Inserter struct {
+	ctx    context.Context
 	client *database.Client
 	schema *database.TableSchema
 	values map[string]any
+	err    error // 解析模型的错误，执行时返回
+
+	returning returning
 }
 
 // Create 创建单条记录
 func (m *Orm[T]) Create() (int64, error) {
-	return m.buildInserter().Run()
+	before, after := createHooks(m.Data)
+	return runHooks(m.context(), m.Client, before, func(ctx context.Context) (int64, error) {
+		return m.buildInserter().RunContext(ctx)
+	}, after)
 }
 
 // Run 执行插入
-//
-// TODO: 实现 RETURNING 语法
 func (i *Inserter) Run() (int64, error) {
+	return i.RunContext(i.ctx)
+}
+
+// RunContext 使用指定上下文执行插入
+func (i *Inserter) RunContext(ctx context.Context) (int64, error) {
+	if i.err != nil {
+		return 0, i.err
+	}
+	ctx = database.WithOperation(ctx, i.schema.TableName, "orm.Create")
 	query := psql.Insert(i.schema.TableName).SetMap(i.values)
-	return i.client.Insert(query)
+	if i.returning.enabled() {
+		return i.returning.run(ctx, i.client, query.Suffix(i.returning.suffix()))
+	}
+	return i.client.InsertContext(ctx, query)
 }
 
 func (m *Orm[T]) buildInserter() *Inserter {
-	schema := database.GetSchema(m.Data)
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return &Inserter{ctx: m.context(), err: err}
+	}
 	inserter := &Inserter{
+		ctx:    m.context(),
 		client: m.Client,
 		schema: schema,
 		values: make(map[string]any),
 	}
+	inserter.returning = m.buildReturning(schema)
 
-	val := reflect.ValueOf(m.Data).Elem()
-	ptr := val.UnsafeAddr()
-
-	for _, field := range schema.Fields {
-		if field.PrimaryKey || field.AutoIncr {
+	ptr := unsafe.Pointer(m.Data)
+	touchCreated(schema, ptr, now())
+	for _, field := range insertFields(schema) {
+		if omitOnInsert(field, ptr) {
 			continue
 		}
-
-		fieldVal := reflect.NewAt(field.GoType, unsafe.Pointer(ptr+field.Offset)).Elem().Interface()
-		inserter.values[field.ColumnName] = fieldVal
+		inserter.values[field.ColumnName] = writeValue(field, ptr)
 	}
 
 	return inserter
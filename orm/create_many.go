@@ -0,0 +1,177 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/skadiD/database"
+)
+
+// maxParams PostgreSQL 单条语句的参数上限
+const maxParams = 65535
+
+// errReturningCopy CopyFrom 无法返回结果
+var errReturningCopy = errors.New("orm: RETURNING is not supported with CopyFrom")
+
+// CreateMany 批量插入，按参数上限拆分为多条多行 INSERT
+//
+// 拆分为多批时在事务中执行；设置 Returning 时结果按顺序写回 rows；
+// 插入钩子对每条记录调用
+func (m *Orm[T]) CreateMany(rows []T) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	before := eachHook[T, BeforeCreator](rows, BeforeCreator.BeforeCreate)
+	after := eachHook[T, AfterCreator](rows, AfterCreator.AfterCreate)
+	return runHooks(m.context(), m.Client, before, func(ctx context.Context) (int64, error) {
+		return m.createMany(ctx, rows)
+	}, after)
+}
+
+// createMany 执行批量插入
+func (m *Orm[T]) createMany(ctx context.Context, rows []T) (int64, error) {
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return 0, err
+	}
+	fields := insertFields(schema)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("orm: no insertable columns on %s", schema.TableName)
+	}
+	ret := m.buildReturning(schema)
+	size := maxParams / len(fields)
+	t := now()
+	for i := range rows {
+		touchCreated(schema, unsafe.Pointer(&rows[i]), t)
+	}
+
+	ctx = database.WithOperation(ctx, schema.TableName, "orm.CreateMany")
+	if len(rows) <= size {
+		return insertBatch(ctx, m.Client, schema, fields, rows, nil, ret)
+	}
+
+	var total int64
+	err = m.Client.Tx(ctx, database.TxOptions{}, func(tx *database.Tx) error {
+		for start := 0; start < len(rows); start += size {
+			end := min(start+size, len(rows))
+			n, err := insertBatch(tx.Context(), tx.Client, schema, fields, rows[start:end], nil, ret)
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// CopyFrom 使用 COPY 协议批量写入，适合大批量导入
+//
+// COPY 不支持 RETURNING，设置 Returning 时返回错误；
+// COPY 无法逐行使用 DEFAULT，default 列仅在全部行均为零值时省略；
+// 插入钩子对每条记录调用，BeforeCreate 在开始 COPY 前全部调用完毕
+func (m *Orm[T]) CopyFrom(rows []T) (int64, error) {
+	if m.returning {
+		return 0, errReturningCopy
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	before := eachHook[T, BeforeCreator](rows, BeforeCreator.BeforeCreate)
+	after := eachHook[T, AfterCreator](rows, AfterCreator.AfterCreate)
+	return runHooks(m.context(), m.Client, before, func(ctx context.Context) (int64, error) {
+		return m.copyFrom(ctx, rows)
+	}, after)
+}
+
+// copyFrom 执行 COPY
+func (m *Orm[T]) copyFrom(ctx context.Context, rows []T) (int64, error) {
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return 0, err
+	}
+	t := now()
+	for i := range rows {
+		touchCreated(schema, unsafe.Pointer(&rows[i]), t)
+	}
+	fields := copyFields(schema, rows)
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.ColumnName
+	}
+
+	i := -1
+	src := pgx.CopyFromFunc(func() ([]any, error) {
+		i++
+		if i >= len(rows) {
+			return nil, nil
+		}
+		values := make([]any, len(fields))
+		for j, field := range fields {
+			values[j] = writeValue(field, unsafe.Pointer(&rows[i]))
+		}
+		return values, nil
+	})
+	ctx = database.WithOperation(ctx, schema.TableName, "orm.CopyFrom")
+	return m.Client.CopyFromContext(ctx, schema.TableName, columns, src)
+}
+
+// insertBatch 以单条多行 INSERT 写入 rows，suffix 非空时追加在 VALUES 之后
+func insertBatch[T any](ctx context.Context, client *database.Client, schema *database.TableSchema,
+	fields []*database.FieldSchema, rows []T, suffix sq.Sqlizer, ret returning) (int64, error) {
+	query := insertQuery(schema, fields, rows, suffix)
+
+	if !ret.enabled() {
+		return client.InsertContext(ctx, query)
+	}
+	if ret.err != nil {
+		return 0, ret.err
+	}
+	i := 0
+	return client.ReturningContext(ctx, query.Suffix(ret.suffix()), func(r pgx.Rows) error {
+		if i >= len(rows) {
+			return nil
+		}
+		base := unsafe.Pointer(&rows[i])
+		i++
+		return r.Scan(ret.targets(base)...)
+	})
+}
+
+// insertQuery 构造多行 INSERT，省略的字段以 DEFAULT 占位
+func insertQuery[T any](schema *database.TableSchema, fields []*database.FieldSchema, rows []T, suffix sq.Sqlizer) sq.InsertBuilder {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.ColumnName
+	}
+	query := psql.Insert(schema.TableName).Columns(columns...)
+	for i := range rows {
+		query = query.Values(insertValues(fields, unsafe.Pointer(&rows[i]))...)
+	}
+	if suffix != nil {
+		query = query.SuffixExpr(suffix)
+	}
+	return query
+}
+
+// copyFields 返回 COPY 写入的字段，省略全部行均可使用默认值的列
+func copyFields[T any](schema *database.TableSchema, rows []T) []*database.FieldSchema {
+	fields := insertFields(schema)
+	kept := fields[:0]
+	for _, field := range fields {
+		for i := range rows {
+			if !omitOnInsert(field, unsafe.Pointer(&rows[i])) {
+				kept = append(kept, field)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -1,8 +1,7 @@
 package orm
 
 import (
-	"reflect"
-	"unsafe"
+	"context"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/skadiD/database"
@@ -10,12 +9,18 @@ import (
 
 // Deleter 删除操作结构体
 type Deleter struct {
+	ctx    context.Context
 	client *database.Client
 	schema *database.TableSchema
 	where  []squirrel.Sqlizer
+	soft   bool  // 软删除，以 UPDATE 标记代替 DELETE
+	model  any   // 调用删除钩子的模型
+	err    error // 解析模型的错误，执行时返回
+
+	returning returning
 }
 
-// Delete 删除
+// Delete 删除，模型含软删除字段时标记删除，Unscoped 时物理删除
 func (m *Orm[T]) Delete() *Deleter {
 	return m.buildDeleter()
 }
@@ -28,28 +33,64 @@ func (d *Deleter) Where(cond squirrel.Eq) *Deleter {
 
 // Run 执行删除
 func (d *Deleter) Run() (int64, error) {
+	return d.RunContext(d.ctx)
+}
+
+// RunContext 使用指定上下文执行删除
+func (d *Deleter) RunContext(ctx context.Context) (int64, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	before, after := deleteHooks(d.model)
+	return runHooks(ctx, d.client, before, d.exec, after)
+}
+
+// exec 执行删除语句
+func (d *Deleter) exec(ctx context.Context) (int64, error) {
+	ctx = database.WithOperation(ctx, d.schema.TableName, "orm.Delete")
+	if d.soft {
+		query := d.softSql()
+		if d.returning.enabled() {
+			return d.returning.run(ctx, d.client, query.Suffix(d.returning.suffix()))
+		}
+		return d.client.UpdateContext(ctx, query)
+	}
+
 	query := psql.Delete(d.schema.TableName).Where(squirrel.And(d.where))
-	return d.client.Delete(query)
+	if d.returning.enabled() {
+		return d.returning.run(ctx, d.client, query.Suffix(d.returning.suffix()))
+	}
+	return d.client.DeleteContext(ctx, query)
+}
+
+// softSql 软删除语句，将软删除字段标记为已删除
+func (d *Deleter) softSql() squirrel.UpdateBuilder {
+	field := d.schema.SoftDelete
+	return psql.Update(d.schema.TableName).
+		Set(field.ColumnName, deletedValue(field, now())).
+		Where(squirrel.And(d.where))
 }
 
 func (m *Orm[T]) buildDeleter() *Deleter {
-	schema := database.GetSchema(m.Data)
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return &Deleter{ctx: m.context(), err: err}
+	}
 	deleter := &Deleter{
+		ctx:    m.context(),
 		client: m.Client,
 		schema: schema,
-		where:  m.where,
+		where:  append([]squirrel.Sqlizer{}, m.where...),
+		soft:   schema.SoftDelete != nil && !m.unscoped,
+		model:  m.Data,
 	}
+	deleter.returning = m.buildReturning(schema)
 
-	if schema.PrimaryKey != nil {
-		var pkVal any
-		if (m.PkVal == nil || database.IsZeroValue(m.PkVal)) && m.Data != nil {
-			val := reflect.ValueOf(m.Data).Elem()
-			ptr := val.UnsafeAddr()
-			pkVal = *(*int64)(unsafe.Pointer(ptr + schema.PrimaryKey.Offset))
-		} else {
-			pkVal = m.PkVal
-		}
-		deleter.where = append(deleter.where, squirrel.Eq{schema.PrimaryKey.ColumnName: pkVal})
+	if pk := m.pkWhere(schema, true); pk != nil {
+		deleter.where = append(deleter.where, pk)
+	}
+	if scope := m.scope(schema); scope != nil {
+		deleter.where = append(deleter.where, scope)
 	}
 
 	return deleter
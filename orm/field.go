@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"encoding/json"
+	"reflect"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// sqlDefault 多行插入中省略的列以 DEFAULT 占位
+var sqlDefault = sq.Expr("DEFAULT")
+
+// insertFields 返回插入时写入的字段，跳过自增与只读列
+func insertFields(schema *database.TableSchema) []*database.FieldSchema {
+	fields := make([]*database.FieldSchema, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.Insertable() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// omitOnInsert 插入时是否省略该字段以使用数据库默认值：default 字段、主键与自增列为零值时省略
+func omitOnInsert(field *database.FieldSchema, base unsafe.Pointer) bool {
+	return (field.Default || field.PrimaryKey || field.AutoIncr) && field.IsZero(base)
+}
+
+// insertValues 返回多行插入时各字段的值，省略的字段为 DEFAULT
+func insertValues(fields []*database.FieldSchema, base unsafe.Pointer) []any {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		if omitOnInsert(field, base) {
+			values[i] = sqlDefault
+			continue
+		}
+		values[i] = writeValue(field, base)
+	}
+	return values
+}
+
+// fieldValues 读取 base 所指结构体中各字段的值
+func fieldValues(fields []*database.FieldSchema, base unsafe.Pointer) []any {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		values[i] = field.Value(base)
+	}
+	return values
+}
+
+// writeValue 返回字段写入数据库的值，处理 nullzero 与 json，软删除字段的零值写入 NULL
+func writeValue(field *database.FieldSchema, base unsafe.Pointer) any {
+	if (field.NullZero || field.SoftDelete) && field.IsZero(base) {
+		return nil
+	}
+	value := field.Value(base)
+	if field.JSON {
+		return jsonValue(value)
+	}
+	return value
+}
+
+// jsonValue 将值序列化为 JSON 文本，nil 指针、map、切片写入 NULL
+func jsonValue(value any) any {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return errValue{err: err}
+	}
+	return string(b)
+}
+
+// errValue 将值的编码错误延迟到生成 SQL 时返回
+type errValue struct {
+	err error
+}
+
+// ToSql 实现 squirrel.Sqlizer
+func (e errValue) ToSql() (string, []any, error) {
+	return "", nil, e.err
+}
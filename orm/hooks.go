@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"context"
+
+	"github.com/skadiD/database"
+)
+
+// BeforeCreator 插入前调用，返回错误时中止插入；Create、CreateMany、CopyFrom、Upsert 与 UpsertMany 均会调用
+type BeforeCreator interface {
+	BeforeCreate(ctx context.Context) error
+}
+
+// AfterCreator 插入后调用，返回错误时回滚插入
+type AfterCreator interface {
+	AfterCreate(ctx context.Context) error
+}
+
+// BeforeUpdater 更新前调用，返回错误时中止更新
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater 更新后调用，返回错误时回滚更新
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter 删除前调用，返回错误时中止删除
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleter 删除后调用，返回错误时回滚删除
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterFinder 查询到记录后逐条调用，返回错误时查询返回该错误；Preload 加载的关联记录同样调用
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// hookFunc 生命周期钩子
+type hookFunc func(ctx context.Context) error
+
+// createHooks 返回 model 实现的插入钩子
+func createHooks(model any) (before, after hookFunc) {
+	if h, ok := model.(BeforeCreator); ok {
+		before = h.BeforeCreate
+	}
+	if h, ok := model.(AfterCreator); ok {
+		after = h.AfterCreate
+	}
+	return before, after
+}
+
+// updateHooks 返回 model 实现的更新钩子
+func updateHooks(model any) (before, after hookFunc) {
+	if h, ok := model.(BeforeUpdater); ok {
+		before = h.BeforeUpdate
+	}
+	if h, ok := model.(AfterUpdater); ok {
+		after = h.AfterUpdate
+	}
+	return before, after
+}
+
+// deleteHooks 返回 model 实现的删除钩子
+func deleteHooks(model any) (before, after hookFunc) {
+	if h, ok := model.(BeforeDeleter); ok {
+		before = h.BeforeDelete
+	}
+	if h, ok := model.(AfterDeleter); ok {
+		after = h.AfterDelete
+	}
+	return before, after
+}
+
+// runHooks 依次执行 before、op、after
+//
+// 存在 after 钩子时三者在同一事务（已有事务时为 SAVEPOINT）中执行，after 返回错误将回滚 op 的写入
+func runHooks(ctx context.Context, client *database.Client, before hookFunc, op func(context.Context) (int64, error), after hookFunc) (int64, error) {
+	if after == nil {
+		if before != nil {
+			if err := before(ctx); err != nil {
+				return 0, err
+			}
+		}
+		return op(ctx)
+	}
+
+	var n int64
+	err := client.Tx(ctx, database.TxOptions{}, func(tx *database.Tx) error {
+		ctx := tx.Context()
+		if before != nil {
+			if err := before(ctx); err != nil {
+				return err
+			}
+		}
+		var err error
+		if n, err = op(ctx); err != nil {
+			return err
+		}
+		return after(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// eachHook 返回对每条记录依次调用钩子的 hookFunc，*T 未实现 H 时返回 nil
+func eachHook[T any, H any](rows []T, call func(H, context.Context) error) hookFunc {
+	var zero T
+	if _, ok := any(&zero).(H); !ok {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		for i := range rows {
+			if err := call(any(&rows[i]).(H), ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+//go:build integration
+
+// 集成测试需要可写的 PostgreSQL：DATABASE_URL=postgres://... go test -tags integration ./...
+
+package orm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+type itItem struct {
+	ID      int64  `orm:"id,pk,auto"`
+	Name    string `orm:"name"`
+	Version int32  `orm:"version,version"`
+}
+
+// newIntegrationClient 连接 DATABASE_URL 并重建 it_item 表，未设置时跳过
+func newIntegrationClient(t *testing.T) *database.Client {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	opts := database.DefaultOptions(url)
+	opts.SkipPreload = true
+	c, err := database.NewClientWithConfig(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"DROP TABLE IF EXISTS it_item",
+		"CREATE TABLE it_item (id bigserial PRIMARY KEY, name text NOT NULL UNIQUE, version int NOT NULL DEFAULT 0)",
+	} {
+		if _, err = c.Exec(ctx, stmt); err != nil {
+			c.Close()
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		_, _ = c.Exec(context.Background(), "DROP TABLE IF EXISTS it_item")
+		c.Close()
+	})
+	if err = database.RegisterModel[itItem]("it_item"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestIntegrationReturning(t *testing.T) {
+	c := newIntegrationClient(t)
+
+	item := &itItem{Name: "a"}
+	if n, err := Model[itItem](c).Load(item).Returning().Create(); err != nil || n != 1 {
+		t.Fatalf("Create = %d, %v", n, err)
+	}
+	if item.ID == 0 || item.Version != 0 {
+		t.Fatalf("RETURNING was not written back: %+v", item)
+	}
+
+	rows := []itItem{{Name: "b"}, {Name: "c"}}
+	if _, err := Model[itItem](c).Returning().CreateMany(rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].ID == 0 || rows[1].ID != rows[0].ID+1 {
+		t.Fatalf("CreateMany RETURNING out of order: %+v", rows)
+	}
+
+	// Save 检查并递增版本号，RETURNING 写回新版本号
+	item.Name = "a2"
+	if n, err := Model[itItem](c).Load(item).Returning().Save(); err != nil || n != 1 {
+		t.Fatalf("Save = %d, %v", n, err)
+	}
+	if item.Version != 1 || item.Name != "a2" {
+		t.Fatalf("Save RETURNING = %+v", item)
+	}
+	stale := &itItem{ID: item.ID, Name: "a3"}
+	var staleErr *database.ErrStaleObject
+	if _, err := Model[itItem](c).Load(stale).Save(); !errors.As(err, &staleErr) {
+		t.Fatalf("err = %v, want ErrStaleObject", err)
+	}
+
+	deleted := &itItem{}
+	if n, err := Model[itItem](c).Load(deleted).Pk(rows[0].ID).Returning().Delete().Run(); err != nil || n != 1 {
+		t.Fatalf("Delete = %d, %v", n, err)
+	}
+	if deleted.Name != "b" {
+		t.Fatalf("Delete RETURNING = %+v", deleted)
+	}
+}
+
+func TestIntegrationUpsert(t *testing.T) {
+	c := newIntegrationClient(t)
+
+	item := &itItem{Name: "a"}
+	if _, err := Model[itItem](c).Load(item).Returning().Upsert().Run(); err != nil {
+		t.Fatal(err)
+	}
+	if item.ID == 0 {
+		t.Fatalf("new row should get a DEFAULT id: %+v", item)
+	}
+
+	// 主键冲突时更新并递增版本号
+	update := &itItem{ID: item.ID, Name: "a2"}
+	if n, err := Model[itItem](c).Load(update).Returning().Upsert().Run(); err != nil || n != 1 {
+		t.Fatalf("Upsert = %d, %v", n, err)
+	}
+	if update.Name != "a2" || update.Version != 1 {
+		t.Fatalf("conflict update = %+v", update)
+	}
+
+	// DO NOTHING 时冲突行不计入
+	if n, err := Model[itItem](c).Load(&itItem{ID: item.ID, Name: "x"}).Upsert().DoNothing().Run(); err != nil || n != 0 {
+		t.Fatalf("DoNothing = %d, %v", n, err)
+	}
+
+	// 批量：按唯一列冲突，带条件的更新只作用于满足条件的行
+	rows := []itItem{{Name: "a2"}, {Name: "b"}}
+	n, err := Model[itItem](c).UpsertMany(rows).OnConflict("name").DoUpdate("name").
+		Where(sq.Expr(`it_item.version < 0`)).Run()
+	if err != nil || n != 1 {
+		t.Fatalf("UpsertMany = %d, %v; want only the new row", n, err)
+	}
+	var names []string
+	if names, err = Pluck[string](Model[itItem](c).Select().OrderBy("name"), "name"); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "a2" || names[1] != "b" {
+		t.Fatalf("names = %v", names)
+	}
+}
@@ -0,0 +1,112 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/skadiD/database"
+)
+
+// Join 以 A 为主表内连接 B，配合 Scan 将结果扫描到同时嵌入 A 与 B 的结构体
+func Join[A, B any](c *database.Client, on string, args ...any) *Selector[A] {
+	return Model[A](c).Select().InnerJoin(new(B), on, args...)
+}
+
+// InnerJoin 内连接 model 对应的表，on 中的列应带表名
+func (s *Selector[T]) InnerJoin(model any, on string, args ...any) *Selector[T] {
+	return s.joinModel("JOIN", model, on, args)
+}
+
+// LeftJoin 左连接 model 对应的表，on 中的列应带表名
+func (s *Selector[T]) LeftJoin(model any, on string, args ...any) *Selector[T] {
+	return s.joinModel("LEFT JOIN", model, on, args)
+}
+
+// joinModel 添加模型连接，被连接模型含软删除字段时在 ON 中排除已删除记录
+func (s *Selector[T]) joinModel(kind string, model any, on string, args []any) *Selector[T] {
+	schema, err := database.LookupSchema(model)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	clause := kind + " " + schema.TableName + " ON " + on
+	if !s.unscoped && schema.SoftDelete != nil {
+		clause = kind + " " + schema.TableName + " ON (" + on + ") AND " + softDeleteCond(schema)
+	}
+	s.joins = append(s.joins, sq.Expr(clause, args...))
+	return s
+}
+
+// Scan 执行查询并将结果扫描到 R
+//
+// R 匿名嵌入参与查询的各模型，嵌入字段的 db 标签作为列前缀，如
+//
+//	type UserOrder struct {
+//		User  `db:"user"`
+//		Order `db:"order"`
+//	}
+//
+// R 未嵌入模型时按 Select 指定的列扫描，列名与字段的 db/orm 标签对应
+func Scan[R, T any](s *Selector[T]) ([]R, error) {
+	return ScanContext[R](s.ctx, s)
+}
+
+// ScanContext 使用指定上下文执行 Scan
+func ScanContext[R, T any](ctx context.Context, s *Selector[T]) ([]R, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	columns, err := joinColumns(reflect.TypeFor[R]())
+	if err != nil {
+		return nil, err
+	}
+	// 未嵌入模型时使用 Select 指定的列，适用于分组聚合
+	if columns == nil {
+		columns = s.columns
+	}
+
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.Scan")
+	var items []R
+	_, err = s.client.ScanContext(ctx, s.sqlWith(columns), func(rows pgx.Rows) error {
+		item, err := database.RowToStructByName[R](rows)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// joinColumns 按 R 中匿名嵌入的模型生成带表名的列，有前缀时以 AS "前缀_列名" 区分同名列
+func joinColumns(typ reflect.Type) ([]string, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("orm: scan target %s is not a struct", typ)
+	}
+	var columns []string
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.Anonymous || sf.Type.Kind() != reflect.Struct {
+			continue
+		}
+		schema, err := database.LookupSchema(reflect.New(sf.Type).Interface())
+		if err != nil {
+			return nil, err
+		}
+		prefix := sf.Tag.Get("db")
+		for _, field := range schema.Fields {
+			col := schema.TableName + "." + field.ColumnName
+			if prefix != "" {
+				col += ` AS "` + prefix + "_" + field.ColumnName + `"`
+			}
+			columns = append(columns, col)
+		}
+	}
+	return columns, nil
+}
@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/skadiD/database"
+)
+
+// Keyset 键集分页查询
+func (s *Selector[T]) Keyset(ks database.Keyset) (database.KeysetPage[T], error) {
+	return s.KeysetContext(s.ctx, ks)
+}
+
+// KeysetContext 使用指定上下文进行键集分页查询
+//
+// ks.OrderBy 中未包含的主键列会追加到排序末尾以保证顺序唯一，为空时按主键升序；
+// 查询上已有的排序与分页被忽略
+func (s *Selector[T]) KeysetContext(ctx context.Context, ks database.Keyset) (database.KeysetPage[T], error) {
+	if s.err != nil {
+		return database.KeysetPage[T]{}, s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.Keyset")
+
+	q := *s
+	q.orderBy, q.limit, q.offset = nil, 0, 0
+	ks.OrderBy = keysetOrder(s.schema, ks.OrderBy)
+	page, err := database.SelectKeysetContext[T](ctx, s.client, q.sql(), ks)
+	if err != nil {
+		return page, err
+	}
+	page.Items, err = s.afterGet(ctx, page.Items)
+	return page, err
+}
+
+// keysetOrder 追加排序中缺少的主键列
+func keysetOrder(schema *database.TableSchema, orderBy []string) []string {
+	cols := make([]string, len(orderBy))
+	for i, clause := range orderBy {
+		col, _, _ := strings.Cut(strings.TrimSpace(clause), " ")
+		if j := strings.LastIndex(col, "."); j >= 0 {
+			col = col[j+1:]
+		}
+		cols[i] = strings.Trim(col, `"`)
+	}
+
+	orderBy = slices.Clone(orderBy)
+	for _, pk := range schema.PrimaryKeys {
+		if !slices.Contains(cols, pk.ColumnName) {
+			orderBy = append(orderBy, schema.TableName+"."+pk.ColumnName)
+		}
+	}
+	return orderBy
+}
@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"context"
+
 	sq "github.com/Masterminds/squirrel"
 	"github.com/skadiD/database"
 )
@@ -10,7 +12,12 @@ type Orm[T any] struct {
 	Data   *T
 	PkVal  any
 
+	ctx   context.Context
 	where []sq.Sqlizer
+
+	returning     bool
+	returningCols []string
+	unscoped      bool
 }
 
 var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
@@ -25,9 +32,16 @@ func (m *Orm[T]) Load(data *T) *Orm[T] {
 	return m
 }
 
-// Pk 设置主键值
-func (m *Orm[T]) Pk(value any) *Orm[T] {
-	m.PkVal = value
+// WithContext 设置后续操作使用的上下文
+func (m *Orm[T]) WithContext(ctx context.Context) *Orm[T] {
+	m.ctx = ctx
+	return m
+}
+
+// WithTx 将后续操作绑定到事务 tx
+func (m *Orm[T]) WithTx(tx *database.Tx) *Orm[T] {
+	m.Client = tx.Client
+	m.ctx = tx.Context()
 	return m
 }
 
@@ -36,3 +50,11 @@ func (m *Orm[T]) Where(where []sq.Sqlizer) *Orm[T] {
 	m.where = where
 	return m
 }
+
+// context 返回当前上下文，未设置时为 context.Background()
+func (m *Orm[T]) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
@@ -1,9 +1,16 @@
 package orm
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
+	"unsafe"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/skadiD/database"
+	"github.com/skadiD/database/types"
 )
 
 type User struct {
@@ -12,6 +19,32 @@ type User struct {
 	Age  int    `orm:"age"`
 }
 
+func TestOrm_InvalidModel(t *testing.T) {
+	// 非结构体模型返回错误而不是 panic
+	type invalid int
+	m := func() *Orm[invalid] { return Model[invalid](nil) }
+	rows := []invalid{1}
+	ops := map[string]func() error{
+		"Create":     func() error { _, err := m().Create(); return err },
+		"CreateMany": func() error { _, err := m().CreateMany(rows); return err },
+		"CopyFrom":   func() error { _, err := m().CopyFrom(rows); return err },
+		"Update":     func() error { _, err := m().Update(); return err },
+		"Updates":    func() error { _, err := m().Pk(1).Updates(map[string]any{"a": 1}); return err },
+		"Delete":     func() error { _, err := m().Pk(1).Delete().Run(); return err },
+		"Restore":    func() error { _, err := m().Pk(1).Restore(); return err },
+		"Upsert":     func() error { _, err := m().Upsert().Run(); return err },
+		"Get":        func() error { _, err := m().Select().Get(); return err },
+		"Count":      func() error { _, err := m().Select().Count(); return err },
+		"Max":        func() error { _, err := Max[int](m().Select(), "a"); return err },
+		"Paginate":   func() error { _, err := m().Select().Paginate(1, 10); return err },
+	}
+	for name, op := range ops {
+		if err := op(); err == nil || errors.Is(err, database.ErrConnection) {
+			t.Errorf("%s: err = %v, want model error", name, err)
+		}
+	}
+}
+
 func TestOrm_Update(t *testing.T) {
 	// 预制一个 user 对象
 	user := &User{
@@ -56,3 +89,740 @@ func BenchmarkRaw_Update(b *testing.B) {
 		psql.Update("user").Set("name", user.Name).Set("age", user.Age).Where("id = ?", user.ID).ToSql()
 	}
 }
+
+func TestOrm_Returning(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	schema := database.GetSchema(&User{})
+
+	r := Model[User](nil).Returning().buildReturning(schema)
+	if got := r.suffix(); got != "RETURNING id, name, age" {
+		t.Fatalf("suffix = %q", got)
+	}
+
+	r = Model[User](nil).Returning("id").buildReturning(schema)
+	if got := r.suffix(); got != "RETURNING id" {
+		t.Fatalf("suffix = %q", got)
+	}
+
+	if r = Model[User](nil).buildReturning(schema); r.enabled() {
+		t.Fatal("returning should be disabled by default")
+	}
+
+	if _, err := Model[User](nil).Returning("missing").Create(); err == nil {
+		t.Fatal("expected error for unknown returning column")
+	}
+	if _, err := Model[User](nil).Returning().Create(); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+func TestOrm_CreateMany(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	schema := database.GetSchema(&User{})
+
+	fields := insertFields(schema)
+	if len(fields) != 2 || fields[0].ColumnName != "name" || fields[1].ColumnName != "age" {
+		t.Fatalf("insertFields = %v", fields)
+	}
+	values := fieldValues(fields, unsafe.Pointer(&User{ID: 1, Name: "a", Age: 2}))
+	if values[0] != "a" || values[1] != 2 {
+		t.Fatalf("fieldValues = %v", values)
+	}
+
+	if n, err := Model[User](nil).CreateMany(nil); n != 0 || err != nil {
+		t.Fatalf("CreateMany(nil) = %d, %v", n, err)
+	}
+	rows := make([]User, maxParams)
+	if _, err := Model[User](nil).CreateMany(rows); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := Model[User](nil).Returning().CopyFrom(rows); !errors.Is(err, errReturningCopy) {
+		t.Fatalf("err = %v, want errReturningCopy", err)
+	}
+	if _, err := Model[User](nil).CopyFrom(rows); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+func TestOrm_Upsert(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	schema := database.GetSchema(&User{})
+
+	tests := []struct {
+		name string
+		u    *Upserter[User]
+		want string
+		args int
+	}{
+		{"default", Model[User](nil).Upsert(),
+			"ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, age = EXCLUDED.age", 0},
+		{"columns", Model[User](nil).Upsert().OnConflict("name").DoUpdate("age"),
+			"ON CONFLICT (name) DO UPDATE SET age = EXCLUDED.age", 0},
+		{"constraint", Model[User](nil).Upsert().OnConstraint("user_name_key").DoNothing(),
+			"ON CONFLICT ON CONSTRAINT user_name_key DO NOTHING", 0},
+		{"where", Model[User](nil).Upsert().DoUpdate("age").Where(sq.Lt{`"user".age`: 30}),
+			`ON CONFLICT (id) DO UPDATE SET age = EXCLUDED.age WHERE ("user".age < ?)`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suffix, err := tt.u.suffix(schema, tt.u.fields(schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql, args, _ := suffix.ToSql()
+			if sql != tt.want || len(args) != tt.args {
+				t.Fatalf("suffix = %q %v, want %q", sql, args, tt.want)
+			}
+		})
+	}
+
+	if fields := Model[User](nil).Upsert().OnConflict("name").fields(schema); len(fields) != 2 {
+		t.Fatalf("auto column should be skipped when not a conflict column: %v", fields)
+	}
+	_ = database.RegisterModel[Membership]("membership")
+	ms := database.GetSchema(&Membership{})
+	mu := Model[Membership](nil).Upsert()
+	var cols []string
+	for _, field := range mu.fields(ms) {
+		cols = append(cols, field.ColumnName)
+	}
+	if !reflect.DeepEqual(cols, []string{"org_id", "user_id", "role"}) {
+		t.Fatalf("composite primary key upsert columns = %v", cols)
+	}
+	if suffix, _ := mu.suffix(ms, mu.fields(ms)); suffix == nil {
+		t.Fatal("expected ON CONFLICT suffix")
+	} else if sql, _, _ := suffix.ToSql(); sql != "ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role" {
+		t.Fatalf("suffix = %q", sql)
+	}
+	_ = database.RegisterModel[Doc]("doc")
+	ds := database.GetSchema(&Doc{})
+	du := Model[Doc](nil).Upsert()
+	if suffix, err := du.suffix(ds, du.fields(ds)); err != nil {
+		t.Fatal(err)
+	} else if sql, _, _ := suffix.ToSql(); sql != `ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, version = "doc".version + 1` {
+		t.Fatalf("versioned suffix = %q", sql)
+	}
+	if size, _ := batchSize(3, sq.Expr("ON CONFLICT (id) DO UPDATE SET age = EXCLUDED.age WHERE age < ? AND name <> ?", 1, "a")); size != (maxParams-2)/3 {
+		t.Fatalf("batch size = %d", size)
+	}
+	if _, err := Model[User](nil).Upsert().DoUpdate("missing").Run(); err == nil {
+		t.Fatal("expected error for unknown update column")
+	}
+	if _, err := Model[User](nil).Returning().UpsertMany(make([]User, 2)).DoNothing().Run(); !errors.Is(err, errReturningSkipped) {
+		t.Fatalf("err = %v, want errReturningSkipped", err)
+	}
+	if _, err := Model[User](nil).Returning().UpsertMany(make([]User, 2)).Where(sq.Expr("true")).Run(); !errors.Is(err, errReturningSkipped) {
+		t.Fatalf("err = %v, want errReturningSkipped", err)
+	}
+
+	// 新行的自增主键为 DEFAULT，避免全部以 id=0 冲突
+	u := Model[User](nil).UpsertMany(nil)
+	sql, args, err := insertQuery(schema, u.fields(schema), []User{{Name: "a"}, {ID: 5, Name: "b"}}, nil).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `INSERT INTO "user" (id,name,age) VALUES (DEFAULT,$1,$2),($3,$4,$5)` || !reflect.DeepEqual(args, []any{"a", 0, int64(5), "b", 0}) {
+		t.Fatalf("upsert values = %s %v", sql, args)
+	}
+	if _, err := Model[User](nil).Upsert().Run(); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+type Membership struct {
+	OrgID  string `orm:"org_id,pk"`
+	UserID int32  `orm:"user_id,pk"`
+	Role   string `orm:"role"`
+}
+
+func TestOrm_CompositePk(t *testing.T) {
+	_ = database.RegisterModel[Membership]("membership")
+	schema := database.GetSchema(&Membership{})
+	if len(schema.PrimaryKeys) != 2 || schema.PrimaryKey != schema.PrimaryKeys[0] {
+		t.Fatalf("PrimaryKeys = %v", schema.PrimaryKeys)
+	}
+
+	tests := []struct {
+		name string
+		m    *Orm[Membership]
+		args []any
+	}{
+		{"data", Model[Membership](nil).Load(&Membership{OrgID: "acme", UserID: 7}), []any{"acme", int32(7)}},
+		{"tuple", Model[Membership](nil).Pk("acme", int32(8)), []any{"acme", int32(8)}},
+		{"map", Model[Membership](nil).Pk(map[string]any{"user_id": 9, "org_id": "acme"}), []any{"acme", 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.m.pkWhere(schema, true).ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != "(org_id, user_id) = (?, ?)" || !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("pkWhere = %q %v", sql, args)
+			}
+		})
+	}
+
+	if _, _, err := Model[Membership](nil).Pk("acme").pkWhere(schema, true).ToSql(); err == nil {
+		t.Fatal("expected error for missing primary key value")
+	}
+	if _, _, err := Model[Membership](nil).Pk(map[string]any{"org_id": "acme"}).pkWhere(schema, true).ToSql(); err == nil {
+		t.Fatal("expected error for missing primary key column")
+	}
+	if Model[Membership](nil).pkWhere(schema, false) != nil {
+		t.Fatal("select without Pk should not filter by primary key")
+	}
+
+	// 非自增主键写入插入列，零值时使用 DEFAULT
+	m := &Membership{OrgID: "acme", UserID: 7, Role: "x"}
+	sql, args, err := psql.Insert(schema.TableName).SetMap(Model[Membership](nil).Load(m).buildInserter().values).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `INSERT INTO "membership" (org_id,role,user_id) VALUES ($1,$2,$3)` || !reflect.DeepEqual(args, []any{"acme", "x", int32(7)}) {
+		t.Fatalf("insert = %s %v", sql, args)
+	}
+	fields := insertFields(schema)
+	if len(fields) != 3 || fields[0].ColumnName != "org_id" || fields[1].ColumnName != "user_id" {
+		t.Fatalf("insertFields = %v", fields)
+	}
+	if got := insertValues(fields, unsafe.Pointer(&Membership{Role: "x"})); !reflect.DeepEqual(got, []any{sqlDefault, sqlDefault, "x"}) {
+		t.Fatalf("zero primary key should be DEFAULT, got %v", got)
+	}
+}
+
+type Article struct {
+	ID        int64          `orm:"id,pk,auto"`
+	Slug      string         `orm:"slug,insertonly"`
+	Views     int64          `orm:"views,readonly"`
+	Status    string         `orm:"status,default"`
+	Note      string         `orm:"note,nullzero"`
+	Meta      map[string]any `orm:"meta,jsonb"`
+	CreatedAt int64          `orm:"created_at,created"`
+}
+
+func TestOrm_Tags(t *testing.T) {
+	_ = database.RegisterModel[Article]("article")
+	a := &Article{Slug: "hello", Views: 3, Meta: map[string]any{"a": 1}, CreatedAt: 1}
+
+	values := Model[Article](nil).Load(a).buildInserter().values
+	want := map[string]any{"slug": "hello", "note": nil, "meta": `{"a":1}`, "created_at": int64(1)}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("insert values = %v, want %v", values, want)
+	}
+
+	values = Model[Article](nil).Load(a).buildUpdater(false).values
+	want = map[string]any{"status": "", "note": nil, "meta": `{"a":1}`}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("save values = %v, want %v", values, want)
+	}
+
+	a.Meta = nil
+	values = Model[Article](nil).Load(a).buildUpdater(true).values
+	if len(values) != 0 {
+		t.Fatalf("update should skip zero values, got %v", values)
+	}
+
+	schema := database.GetSchema(a)
+	rows := []Article{{Status: "draft"}, {}}
+	fields := copyFields(schema, rows)
+	if len(fields) != 5 {
+		t.Fatalf("copyFields = %d fields, want status kept", len(fields))
+	}
+	if got := insertValues(fields, unsafe.Pointer(&rows[1])); !reflect.DeepEqual(got[1], sqlDefault) {
+		t.Fatalf("zero default column should be DEFAULT, got %v", got[1])
+	}
+	if fields = copyFields(schema, rows[1:]); len(fields) != 4 {
+		t.Fatalf("copyFields should omit all-zero default column, got %d", len(fields))
+	}
+}
+
+type Post struct {
+	ID        int64                  `orm:"id,pk,auto"`
+	Title     string                 `orm:"title"`
+	CreatedAt types.JsonTime         `orm:"created_at,created"`
+	UpdatedAt types.ZeroNullJsonTime `orm:"updated_at,updated"`
+	PublishAt *time.Time             `orm:"publish_at"`
+}
+
+func TestOrm_Timestamps(t *testing.T) {
+	_ = database.RegisterModel[Post]("post")
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return fixed })
+	defer SetClock(nil)
+
+	p := &Post{Title: "a"}
+	values := Model[Post](nil).Load(p).buildInserter().values
+	if p.CreatedAt.ToTime() != fixed || time.Time(p.UpdatedAt) != fixed {
+		t.Fatalf("timestamps = %v, %v", p.CreatedAt.ToTime(), time.Time(p.UpdatedAt))
+	}
+	if values["created_at"] != types.JsonTime(fixed) {
+		t.Fatalf("insert created_at = %v", values["created_at"])
+	}
+
+	later := fixed.Add(time.Hour)
+	SetClock(func() time.Time { return later })
+	values = Model[Post](nil).Load(p).buildUpdater(true).values
+	if _, ok := values["created_at"]; ok {
+		t.Fatal("created_at should not be updated")
+	}
+	if values["updated_at"] != types.ZeroNullJsonTime(later) || p.CreatedAt.ToTime() != fixed {
+		t.Fatalf("update values = %v", values)
+	}
+
+	cols := map[string]any{"title": "b"}
+	captured := Model[Post](nil).Pk(1).buildUpdates(cols).values
+	if len(cols) != 1 || captured["updated_at"] != types.ZeroNullJsonTime(later) {
+		t.Fatalf("Updates values = %v, caller map = %v", captured, cols)
+	}
+
+	v, ok := timeValue(database.GetSchema(p).ColumnToField["publish_at"], fixed)
+	if !ok || *v.Interface().(*time.Time) != fixed {
+		t.Fatal("pointer time fields should be supported")
+	}
+}
+
+type Comment struct {
+	ID        int64      `orm:"id,pk,auto"`
+	Body      string     `orm:"body"`
+	DeletedAt *time.Time `orm:"deleted_at,softdelete"`
+}
+
+type Tag struct {
+	ID      int64  `orm:"id,pk,auto"`
+	Name    string `orm:"name"`
+	Deleted bool   `orm:"deleted,softdelete"`
+}
+
+func TestOrm_SoftDelete(t *testing.T) {
+	_ = database.RegisterModel[Comment]("comment")
+	_ = database.RegisterModel[Tag]("tag")
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return fixed })
+	defer SetClock(nil)
+
+	sql, _, _ := Model[Comment](nil).Select("id").sql().ToSql()
+	if sql != `SELECT id FROM "comment" WHERE ("comment".deleted_at IS NULL)` {
+		t.Fatalf("select = %s", sql)
+	}
+	sql, _, _ = Model[Comment](nil).Unscoped().Select("id").sql().ToSql()
+	if sql != `SELECT id FROM "comment"` {
+		t.Fatalf("unscoped select = %s", sql)
+	}
+	sql, _, _ = Model[Tag](nil).Select("id").sql().ToSql()
+	if sql != `SELECT id FROM "tag" WHERE ("tag".deleted IS NOT TRUE)` {
+		t.Fatalf("bool select = %s", sql)
+	}
+
+	d := Model[Comment](nil).Load(&Comment{ID: 3}).Delete()
+	if !d.soft {
+		t.Fatal("delete should be soft by default")
+	}
+	sql, args, _ := d.softSql().ToSql()
+	if sql != `UPDATE "comment" SET deleted_at = $1 WHERE (id = $2 AND "comment".deleted_at IS NULL)` ||
+		*args[0].(*time.Time) != fixed {
+		t.Fatalf("soft delete = %s %v", sql, args)
+	}
+	if Model[Comment](nil).Unscoped().Delete().soft {
+		t.Fatal("unscoped delete should be hard")
+	}
+
+	if _, ok := Model[Comment](nil).Load(&Comment{ID: 3}).buildUpdater(false).values["deleted_at"]; ok {
+		t.Fatal("softdelete field should not be written by Save")
+	}
+	if _, err := Model[User](nil).Pk(1).Restore(); err == nil {
+		t.Fatal("expected error restoring a model without softdelete field")
+	}
+	if _, err := Model[Tag](nil).Pk(1).Restore(); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+type Doc struct {
+	ID      int64  `orm:"id,pk,auto"`
+	Title   string `orm:"title"`
+	Version int32  `orm:"version,version"`
+}
+
+func TestOrm_Version(t *testing.T) {
+	_ = database.RegisterModel[Doc]("doc")
+	d := &Doc{ID: 1, Title: "a", Version: 4}
+
+	u := Model[Doc](nil).Load(d).buildUpdater(false)
+	sql, args, err := psql.Update(u.schema.TableName).SetMap(u.values).Where(sq.And(u.where)).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `UPDATE "doc" SET title = $1, version = version + 1 WHERE (id = $2 AND version = $3)` ||
+		!reflect.DeepEqual(args, []any{"a", int64(1), int32(4)}) {
+		t.Fatalf("update = %s %v", sql, args)
+	}
+	if u.version != int32(4) || u.data == nil {
+		t.Fatalf("version = %v", u.version)
+	}
+
+	bumpVersion(u.schema.Version, u.data)
+	if d.Version != 5 {
+		t.Fatalf("Version = %d, want 5", d.Version)
+	}
+
+	values := Model[Doc](nil).Pk(1).buildUpdates(map[string]any{"title": "b"}).values
+	if _, ok := values["version"].(sq.Sqlizer); !ok {
+		t.Fatalf("Updates should bump version, got %v", values)
+	}
+}
+
+var errInvalid = errors.New("invalid")
+
+type Account struct {
+	ID    int64  `orm:"id,pk,auto"`
+	Email string `orm:"email"`
+}
+
+func (a *Account) BeforeCreate(context.Context) error {
+	if a.Email == "" {
+		return errInvalid
+	}
+	return nil
+}
+
+func (a *Account) AfterFind(context.Context) error {
+	a.Email = "found:" + a.Email
+	return nil
+}
+
+type Audited struct {
+	ID int64 `orm:"id,pk,auto"`
+}
+
+func (*Audited) AfterDelete(context.Context) error { return nil }
+
+func TestOrm_Hooks(t *testing.T) {
+	if _, err := Model[Account](nil).Load(&Account{}).Create(); !errors.Is(err, errInvalid) {
+		t.Fatalf("err = %v, want BeforeCreate error", err)
+	}
+	if _, err := Model[Account](nil).CreateMany([]Account{{Email: "a"}, {}}); !errors.Is(err, errInvalid) {
+		t.Fatalf("err = %v, want BeforeCreate error", err)
+	}
+	if _, err := Model[Account](nil).Load(&Account{Email: "a"}).Create(); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := Model[Account](nil).CopyFrom([]Account{{Email: "a"}, {}}); !errors.Is(err, errInvalid) {
+		t.Fatalf("err = %v, want BeforeCreate error", err)
+	}
+	if _, err := Model[Account](nil).Load(&Account{}).Upsert().Run(); !errors.Is(err, errInvalid) {
+		t.Fatalf("err = %v, want BeforeCreate error", err)
+	}
+	if _, err := Model[Account](nil).UpsertMany([]Account{{Email: "a"}, {}}).Run(); !errors.Is(err, errInvalid) {
+		t.Fatalf("err = %v, want BeforeCreate error", err)
+	}
+
+	// 存在 After 钩子时须在事务中执行
+	called := false
+	op := func(context.Context) (int64, error) {
+		called = true
+		return 1, nil
+	}
+	_, after := deleteHooks(&Audited{})
+	if _, err := runHooks(context.Background(), nil, nil, op, after); !errors.Is(err, database.ErrConnection) || called {
+		t.Fatalf("err = %v, called = %v; want tx error before op", err, called)
+	}
+	if n, err := runHooks(context.Background(), nil, nil, op, nil); n != 1 || err != nil || !called {
+		t.Fatalf("runHooks = %d, %v", n, err)
+	}
+
+	rows := []Account{{Email: "a"}, {Email: "b"}}
+	if err := eachHook[Account, AfterFinder](rows, AfterFinder.AfterFind)(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].Email != "found:a" || rows[1].Email != "found:b" {
+		t.Fatalf("rows = %v", rows)
+	}
+	// 预加载的关联记录
+	child := Account{Email: "c"}
+	if err := afterFindEach(context.Background(), reflect.TypeFor[Account](), []unsafe.Pointer{unsafe.Pointer(&child)}); err != nil || child.Email != "found:c" {
+		t.Fatalf("child = %v, err = %v", child, err)
+	}
+	if err := afterFindEach(context.Background(), reflect.TypeFor[User](), []unsafe.Pointer{unsafe.Pointer(&User{})}); err != nil {
+		t.Fatal(err)
+	}
+	if eachHook[User, AfterFinder](nil, AfterFinder.AfterFind) != nil {
+		t.Fatal("eachHook should be nil for models without the hook")
+	}
+}
+
+type Customer struct {
+	ID     int64   `orm:"id,pk,auto"`
+	Orders []Order `rel:"has_one"`
+}
+
+type Customer2 struct {
+	ID int64 `orm:"id,pk"`
+}
+
+type Order struct {
+	ID         int64      `orm:"id,pk,auto"`
+	CustomerID int32      `orm:"customer_id"`
+	DeletedAt  *time.Time `orm:"deleted_at,softdelete"`
+}
+
+type Group struct {
+	ID   int64  `orm:"id,pk"`
+	Name string `orm:"name"`
+}
+
+func TestOrm_Preload(t *testing.T) {
+	if _, err := database.LookupSchema(&Customer{}); err == nil {
+		t.Fatal("has_one on a slice field should be rejected")
+	}
+
+	type Shop struct {
+		ID      int64      `orm:"id,pk,auto"`
+		Orders  []Order    `rel:"has_many,fk=customer_id"`
+		Groups  []*Group   `rel:"many2many,join=customer_groups,fk=customer_id,join_ref=group_id"`
+		Owner   *Customer2 `rel:"belongs_to,fk=owner_id"`
+		OwnerID *int64     `orm:"owner_id"`
+	}
+	_ = database.RegisterModel[Shop]("shop")
+	schema := database.GetSchema(&Shop{})
+	orders := database.GetSchema(&Order{})
+	groups := database.GetSchema(&Group{})
+
+	rel := schema.Relations["Orders"]
+	_, targetKey, err := relationKeys(schema, orders, rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, _, _ := preloadQuery(orders, rel, targetKey, []int64{1, 2}).ToSql()
+	want := `SELECT "order".id, "order".customer_id, "order".deleted_at FROM "order" ` +
+		`WHERE "order".customer_id = ANY($1) AND "order".deleted_at IS NULL`
+	if sql != want {
+		t.Fatalf("has_many query = %s", sql)
+	}
+
+	rel = schema.Relations["Groups"]
+	_, targetKey, _ = relationKeys(schema, groups, rel)
+	sql, _, _ = preloadQuery(groups, rel, targetKey, []int64{1}).ToSql()
+	want = `SELECT "customer_groups".customer_id, "group".id, "group".name FROM "group" ` +
+		`JOIN "customer_groups" ON "customer_groups".group_id = "group".id WHERE "customer_groups".customer_id = ANY($1)`
+	if sql != want {
+		t.Fatalf("many2many query = %s", sql)
+	}
+
+	// int32 外键与 int64 主键按同一键匹配
+	shops := []Shop{{ID: 1}, {ID: 2}}
+	o1, o2 := &Order{ID: 10, CustomerID: 1}, &Order{ID: 11, CustomerID: 1}
+	_, k1, _ := relationKey(o1.CustomerID)
+	_, k2, _ := relationKey(shops[0].ID)
+	if k1 != k2 {
+		t.Fatalf("keys %v (%T) != %v (%T)", k1, k1, k2, k2)
+	}
+	assignRelation(schema.Relations["Orders"], unsafe.Pointer(&shops[0]), []unsafe.Pointer{unsafe.Pointer(o1), unsafe.Pointer(o2)})
+	assignRelation(schema.Relations["Orders"], unsafe.Pointer(&shops[1]), nil)
+	if len(shops[0].Orders) != 2 || shops[0].Orders[1].ID != 11 || shops[1].Orders == nil {
+		t.Fatalf("orders = %v, %v", shops[0].Orders, shops[1].Orders)
+	}
+
+	// 外键均为 NULL 时不查询，直接回填零值
+	parents := []unsafe.Pointer{unsafe.Pointer(&shops[0]), unsafe.Pointer(&shops[1])}
+	if err = preload(context.Background(), nil, schema, parents, newPreloadTree([]string{"Owner"})); err != nil {
+		t.Fatal(err)
+	}
+	if shops[0].Owner != nil {
+		t.Fatal("Owner should be nil")
+	}
+	if err = preload(context.Background(), nil, schema, parents, newPreloadTree([]string{"Orders.Items"})); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if err = preload(context.Background(), nil, schema, parents, newPreloadTree([]string{"Missing"})); err == nil {
+		t.Fatal("expected error for unknown relation")
+	}
+
+	tree := newPreloadTree([]string{"Orders", "Orders.Items", "Groups"})
+	if len(tree) != 2 || len(tree["Orders"]) != 1 || tree["Orders"]["Items"] == nil {
+		t.Fatalf("tree = %v", tree)
+	}
+}
+
+type ShopOrder struct {
+	User  `db:"u"`
+	Order `db:"o"`
+}
+
+func TestOrm_Join(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	_ = database.RegisterModel[Order]("order")
+
+	cols, err := joinColumns(reflect.TypeFor[ShopOrder]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel := Join[User, Order](nil, `"order".customer_id = "user".id`).Where(sq.Gt{`"user".age`: 18})
+	sql, args, _ := sel.sqlWith(cols).ToSql()
+	want := `SELECT "user".id AS "u_id", "user".name AS "u_name", "user".age AS "u_age", ` +
+		`"order".id AS "o_id", "order".customer_id AS "o_customer_id", "order".deleted_at AS "o_deleted_at" ` +
+		`FROM "user" JOIN "order" ON ("order".customer_id = "user".id) AND "order".deleted_at IS NULL ` +
+		`WHERE ("user".age > $1)`
+	if sql != want || len(args) != 1 {
+		t.Fatalf("join = %s", sql)
+	}
+
+	sql, _, _ = Model[User](nil).Unscoped().Select("id").LeftJoin(&Order{}, `"order".customer_id = "user".id`).sql().ToSql()
+	if sql != `SELECT id FROM "user" LEFT JOIN "order" ON "order".customer_id = "user".id` {
+		t.Fatalf("left join = %s", sql)
+	}
+
+	if cols, err = joinColumns(reflect.TypeFor[User]()); cols != nil || err != nil {
+		t.Fatalf("joinColumns without embedded models = %v, %v", cols, err)
+	}
+	if _, err = Scan[ShopOrder](Model[User](nil).Select().InnerJoin(1, "true")); err == nil {
+		t.Fatal("expected error for invalid join model")
+	}
+	if _, err = Scan[ShopOrder](sel); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+func TestOrm_Aggregate(t *testing.T) {
+	_ = database.RegisterModel[Comment]("comment")
+	sel := func() *Selector[Comment] {
+		return Model[Comment](nil).Select().Where(sq.Eq{"body": "x"}).OrderBy("id").Limit(5)
+	}
+
+	tests := []struct {
+		name  string
+		query sq.Sqlizer
+		want  string
+	}{
+		{"count", sel().countSql(),
+			`SELECT COUNT(*) FROM "comment" WHERE ("comment".deleted_at IS NULL AND body = $1)`},
+		{"count group", sel().GroupBy("body").Having(sq.Expr("COUNT(*) > ?", 1)).countSql(),
+			`SELECT COUNT(*) FROM (SELECT 1 FROM "comment" WHERE ("comment".deleted_at IS NULL AND body = $1) ` +
+				`GROUP BY body HAVING (COUNT(*) > $2)) AS t`},
+		{"exists", sel().existsSql(),
+			`SELECT EXISTS (SELECT 1 FROM "comment" WHERE ("comment".deleted_at IS NULL AND body = $1) ORDER BY id LIMIT 5)`},
+		{"max", sel().aggregateSql("MAX", "created_at"),
+			`SELECT MAX(created_at) FROM "comment" WHERE ("comment".deleted_at IS NULL AND body = $1)`},
+		{"group", Model[Comment](nil).Unscoped().Select("body", "COUNT(*) AS n").GroupBy("body").
+			Having(sq.Expr("COUNT(*) > ?", 1)).sql(),
+			`SELECT body, COUNT(*) AS n FROM "comment" GROUP BY body HAVING (COUNT(*) > $1)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.query.ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.want {
+				t.Fatalf("sql = %s\nwant  %s", sql, tt.want)
+			}
+		})
+	}
+
+	if _, err := sel().Count(); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := Max[time.Time](sel(), "created_at"); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := sel().Avg("id"); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := Pluck[string](sel(), "body"); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+}
+
+func TestOrm_Paginate(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	_ = database.RegisterModel[Order]("order")
+	_ = database.RegisterModel[Membership]("membership")
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		got  pageStrategy
+		want pageStrategy
+	}{
+		{"plain", Model[User](nil).Select().Where(sq.Gt{"age": 18}).strategy(ctx), pageParallel},
+		{"group", Model[User](nil).Select("age", "COUNT(*) AS n").GroupBy("age").strategy(ctx), pageWindow},
+		{"join", Model[User](nil).Select().Join(`"order" ON "order".customer_id = "user".id`).strategy(ctx), pageCte},
+		{"join group", Model[User](nil).Select("name").LeftJoin(&Order{}, "true").GroupBy("name").strategy(ctx), pageWindow},
+		{"join composite pk", Model[Membership](nil).Select().Join(`"user" ON "user".id = user_id`).strategy(ctx), pageDistinct},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("strategy = %d, want %d", tt.got, tt.want)
+			}
+		})
+	}
+
+	// 复合主键连接时按主键分组去重并以行值计数
+	ms := Model[Membership](nil).Select().Join(`"user" ON "user".id = user_id`)
+	sql, _, _ := ms.distinctCountSql().ToSql()
+	if sql != `SELECT COUNT(DISTINCT ("membership".org_id, "membership".user_id)) FROM "membership" JOIN "user" ON "user".id = user_id` {
+		t.Fatalf("distinct count = %s", sql)
+	}
+	if err := ms.checkDistinct(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.Paginate(1, 10); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+
+	// 外层查询不含连接，不能选择被连接表的列
+	joined := Model[User](nil).Select(`"user".name`, `"order".id AS order_id`).Join(`"order" ON "order".customer_id = "user".id`)
+	if err := joined.checkDistinct(); err == nil {
+		t.Fatal("expected error for joined table column")
+	}
+	if _, err := joined.Paginate(1, 10); err == nil || errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want column error before querying", err)
+	}
+	ordered := Model[User](nil).Select().Join(`"order" ON "order".customer_id = "user".id`).OrderBy(`"user".id, "order".created_at DESC`)
+	if _, err := ordered.Paginate(1, 10); err == nil || errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want order column error before querying", err)
+	}
+	if err := Model[User](nil).Select().Join(`"order" ON "order".customer_id = "user".id`).OrderBy(`"user".name DESC`, "id").checkDistinct(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sel := range []*Selector[User]{
+		Model[User](nil).Select(),
+		Model[User](nil).Select().GroupBy("id"),
+		Model[User](nil).Select().Join(`"order" ON "order".customer_id = "user".id`),
+	} {
+		page, err := sel.Paginate(0, 5)
+		if !errors.Is(err, database.ErrConnection) {
+			t.Fatalf("err = %v, want ErrConnection", err)
+		}
+		if page.Page != 1 || page.Size != database.MinPageElements {
+			t.Fatalf("page = %+v", page)
+		}
+	}
+}
+
+func TestOrm_Keyset(t *testing.T) {
+	_ = database.RegisterModel[User]("user")
+	_ = database.RegisterModel[Membership]("membership")
+
+	schema := database.GetSchema(&User{})
+	if got := keysetOrder(schema, nil); !reflect.DeepEqual(got, []string{`"user".id`}) {
+		t.Fatalf("default order = %v", got)
+	}
+	if got := keysetOrder(schema, []string{"age DESC", `"user".id DESC`}); !reflect.DeepEqual(got, []string{"age DESC", `"user".id DESC`}) {
+		t.Fatalf("order with pk = %v", got)
+	}
+	got := keysetOrder(database.GetSchema(&Membership{}), []string{"role", "user_id DESC"})
+	if want := []string{"role", "user_id DESC", `"membership".org_id`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("composite pk order = %v", got)
+	}
+
+	sel := Model[User](nil).Select().Where(sq.Gt{"age": 18}).OrderBy("name").Page(3, 10)
+	if _, err := sel.Keyset(database.Keyset{OrderBy: []string{"age"}}); !errors.Is(err, database.ErrConnection) {
+		t.Fatalf("err = %v, want ErrConnection", err)
+	}
+	if _, err := sel.Keyset(database.Keyset{Cursor: "x.y"}); !errors.Is(err, database.ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
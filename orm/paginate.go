@@ -0,0 +1,242 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// Page 分页结果
+type Page[T any] struct {
+	Items []T
+	// Total 满足条件的记录总数，设置 GroupBy 时为分组数
+	Total   int64
+	Page    uint64
+	Size    uint64
+	Pages   uint64
+	HasNext bool
+}
+
+// pageStrategy 分页统计总数的方式
+type pageStrategy int
+
+const (
+	// pageParallel 数据与 COUNT 并行查询
+	pageParallel pageStrategy = iota
+	// pageWindow 通过 COUNT(*) OVER() 在同一语句中统计
+	pageWindow
+	// pageCte 先按主键去重再分页，避免连接导致的重复计数
+	pageCte
+	// pageDistinct 按主键分组去重，以 COUNT(DISTINCT 主键) 统计，用于复合主键或带 schema 的表
+	pageDistinct
+)
+
+// Paginate 分页查询并返回总数
+func (s *Selector[T]) Paginate(page, size uint64) (Page[T], error) {
+	return s.PaginateContext(s.ctx, page, size)
+}
+
+// PaginateContext 使用指定上下文分页查询并返回总数
+//
+// 页码从 1 开始，每页不少于 database.MinPageElements 条；统计方式自动选择：
+// 存在连接时按主键去重后分页统计（单列主键使用 GetAllByFieldsCte，否则按主键分组并以 COUNT(DISTINCT 主键) 统计），
+// 此时只能选择与排序主表的列；存在分组或处于事务中时使用 COUNT(*) OVER()，否则数据与 COUNT 并行查询
+func (s *Selector[T]) PaginateContext(ctx context.Context, page, size uint64) (Page[T], error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < database.MinPageElements {
+		size = database.MinPageElements
+	}
+	result := Page[T]{Page: page, Size: size}
+	if s.err != nil {
+		return result, s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.Paginate")
+
+	q := *s
+	q.limit, q.offset = size, (page-1)*size
+
+	var (
+		items []T
+		total int64
+		err   error
+	)
+	strategy := q.strategy(ctx)
+	if strategy == pageCte || strategy == pageDistinct {
+		if err = q.checkDistinct(); err != nil {
+			return result, err
+		}
+	}
+	switch strategy {
+	case pageCte:
+		items, total, err = q.paginateCte(ctx, page, size)
+	case pageDistinct:
+		items, total, err = q.paginateDistinct(ctx)
+	case pageWindow:
+		items, total, err = q.paginateWindow(ctx)
+	default:
+		items, total, err = q.withCount(ctx, q.CountContext)
+	}
+	if err != nil {
+		return result, err
+	}
+	if items, err = q.afterGet(ctx, items); err != nil {
+		return result, err
+	}
+
+	result.Items, result.Total = items, total
+	result.Pages = (uint64(total) + size - 1) / size
+	result.HasNext = page < result.Pages
+	return result, nil
+}
+
+// strategy 选择统计总数的方式
+func (s *Selector[T]) strategy(ctx context.Context) pageStrategy {
+	switch {
+	case len(s.joins) > 0 && len(s.groupBy) == 0:
+		// GetAllByFieldsCte 以 "table"."id" 引用主表，不支持带 schema 的表名
+		if len(s.schema.PrimaryKeys) == 1 && !strings.Contains(s.schema.TableName, ".") {
+			return pageCte
+		}
+		return pageDistinct
+	case len(s.groupBy) > 0 || s.client.InTx(ctx):
+		return pageWindow
+	default:
+		return pageParallel
+	}
+}
+
+// checkDistinct 按主键去重分页时外层查询不含连接或按主键分组，只能选择与排序主表的列
+func (s *Selector[T]) checkDistinct() error {
+	if len(s.schema.PrimaryKeys) == 0 {
+		return fmt.Errorf("orm: Paginate with joins on %s requires a primary key", s.schema.TableName)
+	}
+	table := strings.ReplaceAll(s.schema.TableName, `"`, "")
+	for _, col := range s.columns {
+		if !ownColumn(col, table) {
+			return fmt.Errorf("orm: Paginate with joins can only select columns of %s, got %s", s.schema.TableName, col)
+		}
+	}
+	for _, clause := range s.orderBy {
+		for _, col := range strings.Split(clause, ",") {
+			if !ownColumn(col, table) {
+				return fmt.Errorf("orm: Paginate with joins can only order by columns of %s, got %s", s.schema.TableName, col)
+			}
+		}
+	}
+	return nil
+}
+
+// ownColumn 判断 expr 引用的列是否属于 table，未带表名的列视为属于 table
+func ownColumn(expr, table string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(expr), " ")
+	i := strings.LastIndex(name, ".")
+	return i < 0 || strings.ReplaceAll(name[:i], `"`, "") == table
+}
+
+// withCount 查询数据并以 count 统计总数，不在事务中时两者并行执行
+func (s *Selector[T]) withCount(ctx context.Context, count func(context.Context) (int64, error)) ([]T, int64, error) {
+	if s.client.InTx(ctx) {
+		items, err := database.SelectContext[T](ctx, s.client, s.sql())
+		if err != nil {
+			return nil, 0, err
+		}
+		total, err := count(ctx)
+		return items, total, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		total    int64
+		countErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		total, countErr = count(ctx)
+	}()
+	items, err := database.SelectContext[T](ctx, s.client, s.sql())
+	wg.Wait()
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, countErr
+}
+
+// paginateDistinct 按主键分组去重连接产生的重复行，以 COUNT(DISTINCT 主键) 统计
+func (s *Selector[T]) paginateDistinct(ctx context.Context) ([]T, int64, error) {
+	q := *s
+	q.groupBy = s.pkColumns()
+	return q.withCount(ctx, s.distinctCount)
+}
+
+// distinctCount 统计不重复的主键数
+func (s *Selector[T]) distinctCount(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.value(ctx, "orm.Count", s.distinctCountSql, &n)
+	return n, err
+}
+
+// distinctCountSql 统计不重复主键数的语句，复合主键以行值 (a, b) 计数
+func (s *Selector[T]) distinctCountSql() sq.SelectBuilder {
+	return s.baseSql([]string{"COUNT(DISTINCT (" + strings.Join(s.pkColumns(), ", ") + "))"})
+}
+
+// pkColumns 带表名的主键列
+func (s *Selector[T]) pkColumns() []string {
+	cols := make([]string, len(s.schema.PrimaryKeys))
+	for i, field := range s.schema.PrimaryKeys {
+		cols[i] = s.schema.TableName + "." + field.ColumnName
+	}
+	return cols
+}
+
+// paginateWindow 通过 COUNT(*) OVER() 统计，当前页为空时退回 COUNT
+func (s *Selector[T]) paginateWindow(ctx context.Context) ([]T, int64, error) {
+	items, total, err := database.SelectCountOverContext[T](ctx, s.client, s.sql())
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(items) == 0 && s.offset > 0 {
+		n, err := s.CountContext(ctx)
+		return items, n, err
+	}
+	return items, int64(total), nil
+}
+
+// paginateCte 在预选查询中完成连接与条件筛选并按主键去重，主查询只读取当前页的记录
+func (s *Selector[T]) paginateCte(ctx context.Context, page, size uint64) ([]T, int64, error) {
+	var sort []string
+	if len(s.orderBy) > 0 {
+		sort = []string{strings.Join(s.orderBy, ", ")}
+	}
+	items, total, err := database.GetAllByFieldsCteContext[T](ctx, s.client,
+		strings.Trim(s.schema.TableName, `"`), s.schema.PrimaryKey.ColumnName, sort, page, size,
+		func(b sq.SelectBuilder) sq.SelectBuilder {
+			for _, join := range s.joins {
+				b = b.JoinClause(join)
+			}
+			if len(s.where) > 0 {
+				b = b.Where(sq.And(s.where))
+			}
+			return b
+		},
+		func(b sq.SelectBuilder) sq.SelectBuilder {
+			return b.Columns(s.columns...)
+		},
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 当前页为空时 GetAllByFieldsCte 无法得到总数，按主键去重计数
+	if len(items) == 0 && page > 1 {
+		n, err := s.distinctCount(ctx)
+		return items, n, err
+	}
+	return items, int64(total), nil
+}
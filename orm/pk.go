@@ -0,0 +1,101 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// Pk 设置主键值
+//
+// 单主键传入一个值；联合主键按声明顺序传入多个值，或传入以列名为键的 map[string]any
+func (m *Orm[T]) Pk(values ...any) *Orm[T] {
+	switch len(values) {
+	case 0:
+		m.PkVal = nil
+	case 1:
+		m.PkVal = values[0]
+	default:
+		m.PkVal = values
+	}
+	return m
+}
+
+// hasPk 是否通过 Pk 设置了主键
+func (m *Orm[T]) hasPk() bool {
+	return m.PkVal != nil && !reflect.ValueOf(m.PkVal).IsZero()
+}
+
+// pkCond 主键条件，单主键为 a = ?，联合主键为 (a, b) = (?, ?)
+type pkCond struct {
+	cols []string
+	vals []any
+	err  error
+}
+
+// ToSql 实现 squirrel.Sqlizer
+func (c pkCond) ToSql() (string, []any, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	if len(c.cols) == 1 {
+		return c.cols[0] + " = ?", c.vals, nil
+	}
+	placeholders := strings.Repeat(", ?", len(c.cols))[2:]
+	return "(" + strings.Join(c.cols, ", ") + ") = (" + placeholders + ")", c.vals, nil
+}
+
+// pkWhere 返回主键条件，优先使用 Pk 设置的值，fromData 时回退到 Data 中的主键字段
+//
+// 表无主键或没有可用的值时返回 nil
+func (m *Orm[T]) pkWhere(schema *database.TableSchema, fromData bool) sq.Sqlizer {
+	pks := schema.PrimaryKeys
+	if len(pks) == 0 {
+		return nil
+	}
+	cond := pkCond{cols: make([]string, len(pks))}
+	for i, field := range pks {
+		cond.cols[i] = field.ColumnName
+	}
+
+	switch {
+	case m.hasPk():
+		cond.vals, cond.err = pkValues(schema, m.PkVal)
+	case fromData && m.Data != nil:
+		cond.vals = fieldValues(pks, unsafe.Pointer(m.Data))
+	default:
+		return nil
+	}
+	return cond
+}
+
+// pkValues 将 Pk 设置的值按主键声明顺序展开
+func pkValues(schema *database.TableSchema, value any) ([]any, error) {
+	pks := schema.PrimaryKeys
+	switch v := value.(type) {
+	case map[string]any:
+		vals := make([]any, len(pks))
+		for i, field := range pks {
+			val, ok := v[field.ColumnName]
+			if !ok {
+				return nil, fmt.Errorf("orm: missing primary key %q on %s", field.ColumnName, schema.TableName)
+			}
+			vals[i] = val
+		}
+		return vals, nil
+	case []any:
+		if len(v) != len(pks) {
+			return nil, fmt.Errorf("orm: %s has %d primary key columns, got %d values", schema.TableName, len(pks), len(v))
+		}
+		return v, nil
+	default:
+		if len(pks) != 1 {
+			return nil, fmt.Errorf("orm: %s has %d primary key columns, got 1 value", schema.TableName, len(pks))
+		}
+		return []any{v}, nil
+	}
+}
@@ -0,0 +1,269 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/skadiD/database"
+)
+
+// Preload 预加载关联，嵌套关联以 . 分隔，如 Preload("Orders", "Orders.Items")
+//
+// 每个关联执行一次 WHERE key = ANY($1) 批量查询，结果按键回填到各条记录
+func (s *Selector[T]) Preload(paths ...string) *Selector[T] {
+	s.preloads = append(s.preloads, paths...)
+	return s
+}
+
+// preloadTree 预加载路径树
+type preloadTree map[string]preloadTree
+
+// newPreloadTree 将 Orders.Items 形式的路径展开为树
+func newPreloadTree(paths []string) preloadTree {
+	tree := preloadTree{}
+	for _, path := range paths {
+		node := tree
+		for _, name := range strings.Split(path, ".") {
+			if node[name] == nil {
+				node[name] = preloadTree{}
+			}
+			node = node[name]
+		}
+	}
+	return tree
+}
+
+// preload 为 parents 中的记录加载 tree 中的关联，parents 为 schema 对应结构体的指针
+func preload(ctx context.Context, client *database.Client, schema *database.TableSchema, parents []unsafe.Pointer, tree preloadTree) error {
+	for _, name := range slices.Sorted(maps.Keys(tree)) {
+		rel, ok := schema.Relations[name]
+		if !ok {
+			return fmt.Errorf("orm: %s has no relation %q", schema.GoType, name)
+		}
+		target, err := database.LookupSchema(reflect.New(rel.Target).Interface())
+		if err != nil {
+			return err
+		}
+		if err = preloadRelation(ctx, client, schema, target, rel, parents, tree[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadRelation 加载单个关联并回填
+func preloadRelation(ctx context.Context, client *database.Client, schema, target *database.TableSchema,
+	rel *database.RelationSchema, parents []unsafe.Pointer, sub preloadTree) error {
+	ownerKey, targetKey, err := relationKeys(schema, target, rel)
+	if err != nil {
+		return err
+	}
+
+	// 收集去重后的键，参数使用字段原类型的切片以便编码为数组
+	keyType := ownerKey.GoType
+	if keyType.Kind() == reflect.Ptr {
+		keyType = keyType.Elem()
+	}
+	seen := make(map[any]struct{}, len(parents))
+	param := reflect.MakeSlice(reflect.SliceOf(keyType), 0, len(parents))
+	for _, p := range parents {
+		rv, key, ok := relationKey(ownerKey.Value(p))
+		if !ok {
+			continue
+		}
+		if _, dup := seen[key]; !dup {
+			seen[key] = struct{}{}
+			param = reflect.Append(param, rv)
+		}
+	}
+
+	groups := make(map[any][]unsafe.Pointer)
+	if param.Len() > 0 {
+		query := preloadQuery(target, rel, targetKey, param.Interface())
+		ctx = database.WithOperation(ctx, target.TableName, "orm.Preload")
+		var children []unsafe.Pointer
+		_, err = client.ScanContext(ctx, query, func(rows pgx.Rows) error {
+			base := reflect.New(rel.Target).UnsafePointer()
+			dest := make([]any, 0, len(target.Fields)+1)
+			var joinKey reflect.Value
+			if rel.Kind == database.ManyToMany {
+				joinKey = reflect.New(keyType)
+				dest = append(dest, joinKey.Interface())
+			}
+			for _, field := range target.Fields {
+				dest = append(dest, field.Ptr(base))
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			var owner any
+			if rel.Kind == database.ManyToMany {
+				owner = joinKey.Elem().Interface()
+			} else {
+				owner = targetKey.Value(base)
+			}
+			if _, key, ok := relationKey(owner); ok {
+				groups[key] = append(groups[key], base)
+				children = append(children, base)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(sub) > 0 && len(children) > 0 {
+			if err = preload(ctx, client, target, children, sub); err != nil {
+				return err
+			}
+		}
+		if err = afterFindEach(ctx, rel.Target, children); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range parents {
+		var list []unsafe.Pointer
+		if _, key, ok := relationKey(ownerKey.Value(p)); ok {
+			list = groups[key]
+		}
+		assignRelation(rel, p, list)
+	}
+	return nil
+}
+
+// afterFindEach 对 typ 实现了 AfterFinder 的关联记录逐条调用 AfterFind
+func afterFindEach(ctx context.Context, typ reflect.Type, children []unsafe.Pointer) error {
+	if !reflect.PointerTo(typ).Implements(reflect.TypeFor[AfterFinder]()) {
+		return nil
+	}
+	for _, child := range children {
+		if err := reflect.NewAt(typ, child).Interface().(AfterFinder).AfterFind(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relationKeys 返回本表侧与目标表侧用于匹配的字段，many2many 的目标侧为目标表主键
+func relationKeys(schema, target *database.TableSchema, rel *database.RelationSchema) (owner, other *database.FieldSchema, err error) {
+	lookup := func(s *database.TableSchema, col string) (*database.FieldSchema, error) {
+		if col == "" {
+			if s.PrimaryKey == nil {
+				return nil, fmt.Errorf("orm: relation %s requires a primary key on %s", rel.Name, s.TableName)
+			}
+			return s.PrimaryKey, nil
+		}
+		field, ok := s.ColumnToField[col]
+		if !ok {
+			return nil, fmt.Errorf("orm: relation %s: column %q not found on %s", rel.Name, col, s.TableName)
+		}
+		return field, nil
+	}
+
+	switch rel.Kind {
+	case database.BelongsTo:
+		if owner, err = lookup(schema, rel.ForeignKey); err != nil {
+			return nil, nil, err
+		}
+		other, err = lookup(target, rel.References)
+	case database.HasOne, database.HasMany:
+		if owner, err = lookup(schema, rel.References); err != nil {
+			return nil, nil, err
+		}
+		other, err = lookup(target, rel.ForeignKey)
+	default:
+		if owner, err = lookup(schema, rel.References); err != nil {
+			return nil, nil, err
+		}
+		other, err = lookup(target, "")
+	}
+	return owner, other, err
+}
+
+// preloadQuery 构造关联查询，many2many 额外选出中间表中指向本表的列
+func preloadQuery(target *database.TableSchema, rel *database.RelationSchema, targetKey *database.FieldSchema, keys any) sq.SelectBuilder {
+	cols := make([]string, 0, len(target.Fields)+1)
+	var query sq.SelectBuilder
+	if rel.Kind == database.ManyToMany {
+		join := `"` + rel.JoinTable + `"`
+		cols = append(cols, join+"."+rel.ForeignKey)
+		for _, field := range target.Fields {
+			cols = append(cols, target.TableName+"."+field.ColumnName)
+		}
+		query = psql.Select(cols...).From(target.TableName).
+			Join(join+" ON "+join+"."+rel.JoinReferences+" = "+target.TableName+"."+targetKey.ColumnName).
+			Where(join+"."+rel.ForeignKey+" = ANY(?)", keys)
+	} else {
+		for _, field := range target.Fields {
+			cols = append(cols, target.TableName+"."+field.ColumnName)
+		}
+		query = psql.Select(cols...).From(target.TableName).
+			Where(target.TableName+"."+targetKey.ColumnName+" = ANY(?)", keys)
+	}
+	if target.SoftDelete != nil {
+		query = query.Where(softDeleteCond(target))
+	}
+	return query
+}
+
+// assignRelation 将 children 回填到 parent 的关联字段
+func assignRelation(rel *database.RelationSchema, parent unsafe.Pointer, children []unsafe.Pointer) {
+	field := reflect.NewAt(rel.GoType, unsafe.Add(parent, rel.Offset)).Elem()
+	elem := func(child unsafe.Pointer) reflect.Value {
+		v := reflect.NewAt(rel.Target, child)
+		if rel.Ptr {
+			return v
+		}
+		return v.Elem()
+	}
+
+	switch rel.Kind {
+	case database.HasMany, database.ManyToMany:
+		list := reflect.MakeSlice(rel.GoType, 0, len(children))
+		for _, child := range children {
+			list = reflect.Append(list, elem(child))
+		}
+		field.Set(list)
+	default:
+		if len(children) == 0 {
+			field.SetZero()
+			return
+		}
+		field.Set(elem(children[0]))
+	}
+}
+
+// relationKey 返回用于匹配的键：解引用指针，整数统一为 int64，不可比较的值转为字符串
+//
+// rv 为解引用后的原始值，nil 指针返回 false
+func relationKey(value any) (rv reflect.Value, key any, ok bool) {
+	rv = reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return rv, nil, false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv, rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv, int64(rv.Uint()), true
+	case reflect.String:
+		return rv, rv.String(), true
+	}
+	if rv.Type().Comparable() {
+		return rv, rv.Interface(), true
+	}
+	return rv, fmt.Sprint(rv.Interface()), true
+}
@@ -0,0 +1,87 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/skadiD/database"
+)
+
+// returning RETURNING 子句，fields 为空时不启用
+type returning struct {
+	fields []*database.FieldSchema
+	dest   unsafe.Pointer
+	err    error
+}
+
+// Returning 使 Create/Update/Save/Delete 附带 RETURNING 并将首行写回 Data
+//
+// 未指定 cols 时返回全部字段
+func (m *Orm[T]) Returning(cols ...string) *Orm[T] {
+	m.returning = true
+	m.returningCols = cols
+	return m
+}
+
+// buildReturning 按当前设置解析 RETURNING 字段
+func (m *Orm[T]) buildReturning(schema *database.TableSchema) returning {
+	if !m.returning {
+		return returning{}
+	}
+	r := returning{dest: unsafe.Pointer(m.Data)}
+	if len(m.returningCols) == 0 {
+		r.fields = schema.Fields
+		return r
+	}
+	for _, col := range m.returningCols {
+		field, ok := schema.ColumnToField[col]
+		if !ok {
+			r.err = fmt.Errorf("orm: unknown returning column %q on %s", col, schema.TableName)
+			return r
+		}
+		r.fields = append(r.fields, field)
+	}
+	return r
+}
+
+// enabled 是否启用 RETURNING
+func (r returning) enabled() bool {
+	return r.fields != nil || r.err != nil
+}
+
+// suffix 返回 RETURNING 子句
+func (r returning) suffix() string {
+	cols := make([]string, len(r.fields))
+	for i, field := range r.fields {
+		cols[i] = field.ColumnName
+	}
+	return "RETURNING " + strings.Join(cols, ", ")
+}
+
+// run 执行带 RETURNING 的语句，首行写回 dest，其余行仅计数
+func (r returning) run(ctx context.Context, client *database.Client, query sq.Sqlizer) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	first := true
+	return client.ReturningContext(ctx, query, func(rows pgx.Rows) error {
+		if !first {
+			return nil
+		}
+		first = false
+		return rows.Scan(r.targets(r.dest)...)
+	})
+}
+
+// targets 返回 base 所指结构体中 RETURNING 字段的扫描目标
+func (r returning) targets(base unsafe.Pointer) []any {
+	dest := make([]any, len(r.fields))
+	for i, field := range r.fields {
+		dest[i] = field.Ptr(base)
+	}
+	return dest
+}
@@ -1,12 +1,16 @@
 package orm
 
 import (
+	"context"
+	"unsafe"
+
 	"github.com/Masterminds/squirrel"
 	"github.com/skadiD/database"
 )
 
 // Selector 查询操作结构体
 type Selector[T any] struct {
+	ctx     context.Context
 	client  *database.Client
 	schema  *database.TableSchema
 	columns []string
@@ -14,29 +18,44 @@ type Selector[T any] struct {
 	orderBy []string
 	limit   uint64
 	offset  uint64
-	joins   []string
+	joins   []squirrel.Sqlizer
+	groupBy []string
+	having  []squirrel.Sqlizer
+
+	preloads []string
+	unscoped bool
+	err      error
 }
 
 // Select 初始化查询
 func (m *Orm[T]) Select(cols ...string) *Selector[T] {
-	schema := database.GetSchema(m.Data)
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return &Selector[T]{ctx: m.context(), client: m.Client, err: err}
+	}
 	selector := &Selector[T]{
+		ctx:     m.context(),
 		client:  m.Client,
 		schema:  schema,
 		columns: cols,
-		where:   m.where,
+		where:   append([]squirrel.Sqlizer{}, m.where...),
+
+		unscoped: m.unscoped,
 	}
 
-	// 默认选择所有字段
+	// 默认选择所有字段，列名带表名以便连接查询
 	if len(cols) == 0 {
 		for _, field := range schema.Fields {
-			selector.columns = append(selector.columns, field.ColumnName)
+			selector.columns = append(selector.columns, schema.TableName+"."+field.ColumnName)
 		}
 	}
 
 	// 增加快速主键查询
-	if schema.PrimaryKey != nil && !database.IsZeroValue(m.PkVal) {
-		selector.where = append(selector.where, squirrel.Eq{schema.PrimaryKey.ColumnName: m.PkVal})
+	if pk := m.pkWhere(schema, false); pk != nil {
+		selector.where = append(selector.where, pk)
+	}
+	if scope := m.scope(schema); scope != nil {
+		selector.where = append(selector.where, scope)
 	}
 
 	return selector
@@ -75,31 +94,90 @@ func (s *Selector[T]) Offset(offset uint64) *Selector[T] {
 
 // Join 添加连接
 func (s *Selector[T]) Join(join string) *Selector[T] {
-	s.joins = append(s.joins, join)
+	s.joins = append(s.joins, squirrel.Expr("JOIN "+join))
 	return s
 }
 
 // Get 多条查询
 func (s *Selector[T]) Get() ([]T, error) {
-	return database.Select[T](s.client.Client, s.sql())
+	return s.GetContext(s.ctx)
+}
+
+// GetContext 使用指定上下文进行多条查询
+func (s *Selector[T]) GetContext(ctx context.Context) ([]T, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.Get")
+	items, err := database.SelectContext[T](ctx, s.client, s.sql())
+	if err != nil {
+		return nil, err
+	}
+	return s.afterGet(ctx, items)
+}
+
+// afterGet 加载关联并调用 AfterFind 钩子
+func (s *Selector[T]) afterGet(ctx context.Context, items []T) ([]T, error) {
+	var err error
+	if len(s.preloads) > 0 && len(items) > 0 {
+		parents := make([]unsafe.Pointer, len(items))
+		for i := range items {
+			parents[i] = unsafe.Pointer(&items[i])
+		}
+		if err = preload(ctx, s.client, s.schema, parents, newPreloadTree(s.preloads)); err != nil {
+			return nil, err
+		}
+	}
+	if after := eachHook[T, AfterFinder](items, AfterFinder.AfterFind); after != nil {
+		if err = after(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
 }
 
 // One 获取单条记录
 func (s *Selector[T]) One() (*T, error) {
+	return s.OneContext(s.ctx)
+}
+
+// OneContext 使用指定上下文获取单条记录
+func (s *Selector[T]) OneContext(ctx context.Context) (*T, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	ctx = database.WithOperation(ctx, s.schema.TableName, "orm.One")
 	query := s.sql().Limit(1)
-	return database.Get[T](s.client.Client, query)
+	item, err := database.GetContext[T](ctx, s.client, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.preloads) > 0 {
+		parents := []unsafe.Pointer{unsafe.Pointer(item)}
+		if err = preload(ctx, s.client, s.schema, parents, newPreloadTree(s.preloads)); err != nil {
+			return nil, err
+		}
+	}
+	if h, ok := any(item).(AfterFinder); ok {
+		if err = h.AfterFind(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
 }
 
 func (s *Selector[T]) sql() squirrel.SelectBuilder {
-	query := psql.Select(s.columns...).
-		From(s.schema.TableName)
+	return s.sqlWith(s.columns)
+}
 
-	for _, join := range s.joins {
-		query = query.Join(join)
+// sqlWith 以指定列构造查询
+func (s *Selector[T]) sqlWith(columns []string) squirrel.SelectBuilder {
+	query := s.baseSql(columns)
+	if len(s.groupBy) > 0 {
+		query = query.GroupBy(s.groupBy...)
 	}
-
-	if len(s.where) > 0 {
-		query = query.Where(squirrel.And(s.where))
+	if len(s.having) > 0 {
+		query = query.Having(squirrel.And(s.having))
 	}
 
 	if len(s.orderBy) > 0 {
@@ -115,3 +193,18 @@ func (s *Selector[T]) sql() squirrel.SelectBuilder {
 
 	return query
 }
+
+// baseSql 构造只含连接与条件的查询，不含分组、排序与分页
+func (s *Selector[T]) baseSql(columns []string) squirrel.SelectBuilder {
+	query := psql.Select(columns...).
+		From(s.schema.TableName)
+
+	for _, join := range s.joins {
+		query = query.JoinClause(join)
+	}
+
+	if len(s.where) > 0 {
+		query = query.Where(squirrel.And(s.where))
+	}
+	return query
+}
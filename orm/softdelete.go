@@ -0,0 +1,92 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// Unscoped 忽略软删除：查询包含已删除记录，Delete 执行物理删除
+func (m *Orm[T]) Unscoped() *Orm[T] {
+	m.unscoped = true
+	return m
+}
+
+// Restore 恢复软删除的记录
+func (m *Orm[T]) Restore() (int64, error) {
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return 0, err
+	}
+	field := schema.SoftDelete
+	if field == nil {
+		return 0, fmt.Errorf("orm: %s has no softdelete field", schema.TableName)
+	}
+
+	where := append([]sq.Sqlizer{}, m.where...)
+	if pk := m.pkWhere(schema, true); pk != nil {
+		where = append(where, pk)
+	}
+	if len(where) == 0 {
+		return 0, errors.New("orm: Restore requires a primary key or where condition")
+	}
+	where = append(where, sq.Expr("NOT ("+softDeleteCond(schema)+")"))
+
+	ctx := database.WithOperation(m.context(), schema.TableName, "orm.Restore")
+	query := psql.Update(schema.TableName).Set(field.ColumnName, restoreValue(field)).Where(sq.And(where))
+	if field := schema.UpdatedAt; field != nil {
+		if v, ok := timeValue(field, now()); ok {
+			query = query.Set(field.ColumnName, v.Interface())
+		}
+	}
+	return m.Client.UpdateContext(ctx, query)
+}
+
+// scope 返回排除已软删除记录的条件，无软删除字段或 Unscoped 时为 nil
+func (m *Orm[T]) scope(schema *database.TableSchema) sq.Sqlizer {
+	if m.unscoped || schema.SoftDelete == nil {
+		return nil
+	}
+	return sq.Expr(softDeleteCond(schema))
+}
+
+// softDeleteCond 未删除记录的条件，列名带表名以便用于连接查询
+func softDeleteCond(schema *database.TableSchema) string {
+	col := schema.TableName + "." + schema.SoftDelete.ColumnName
+	if isBoolField(schema.SoftDelete) {
+		return col + " IS NOT TRUE"
+	}
+	return col + " IS NULL"
+}
+
+// deletedValue 软删除时写入的值：布尔字段为 true，时间字段为当前时间
+func deletedValue(field *database.FieldSchema, t time.Time) any {
+	if isBoolField(field) {
+		return true
+	}
+	if v, ok := timeValue(field, t); ok {
+		return v.Interface()
+	}
+	return t
+}
+
+// restoreValue 恢复时写入的值：布尔字段为 false，时间字段为 NULL
+func restoreValue(field *database.FieldSchema) any {
+	if isBoolField(field) {
+		return false
+	}
+	return nil
+}
+
+// isBoolField 是否为布尔（或布尔指针）字段
+func isBoolField(field *database.FieldSchema) bool {
+	typ := field.GoType
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Bool
+}
@@ -0,0 +1,74 @@
+package orm
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/skadiD/database"
+)
+
+var (
+	clock    atomic.Pointer[func() time.Time]
+	timeType = reflect.TypeFor[time.Time]()
+)
+
+// SetClock 设置自动时间戳使用的时钟，传入 nil 恢复为 time.Now
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		clock.Store(nil)
+		return
+	}
+	clock.Store(&fn)
+}
+
+// now 返回当前时钟时间
+func now() time.Time {
+	if fn := clock.Load(); fn != nil {
+		return (*fn)()
+	}
+	return time.Now()
+}
+
+// touchCreated 插入前填充为零值的创建与更新时间
+func touchCreated(schema *database.TableSchema, base unsafe.Pointer, t time.Time) {
+	for _, field := range []*database.FieldSchema{schema.CreatedAt, schema.UpdatedAt} {
+		if field != nil && field.IsZero(base) {
+			setTime(field, base, t)
+		}
+	}
+}
+
+// touchUpdated 更新前写入更新时间
+func touchUpdated(schema *database.TableSchema, base unsafe.Pointer, t time.Time) {
+	if schema.UpdatedAt != nil {
+		setTime(schema.UpdatedAt, base, t)
+	}
+}
+
+// timeValue 将 t 转换为字段类型的值，字段类型不支持时返回 false
+//
+// 支持 time.Time、types.JsonTime、types.ZeroNullJsonTime 等底层类型为 time.Time 的类型及其指针
+func timeValue(field *database.FieldSchema, t time.Time) (reflect.Value, bool) {
+	typ := field.GoType
+	if typ.Kind() == reflect.Ptr {
+		if !timeType.ConvertibleTo(typ.Elem()) {
+			return reflect.Value{}, false
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(reflect.ValueOf(t).Convert(typ.Elem()))
+		return ptr, true
+	}
+	if !timeType.ConvertibleTo(typ) {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(t).Convert(typ), true
+}
+
+// setTime 将 t 写入 base 所指结构体的时间字段
+func setTime(field *database.FieldSchema, base unsafe.Pointer, t time.Time) {
+	if v, ok := timeValue(field, t); ok {
+		reflect.NewAt(field.GoType, unsafe.Add(base, field.Offset)).Elem().Set(v)
+	}
+}
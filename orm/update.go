@@ -1,7 +1,10 @@
 package orm
 
 import (
-	"reflect"
+	"context"
+	"maps"
+	"slices"
+	"strings"
 	"unsafe"
 
 	"github.com/Masterminds/squirrel"
@@ -10,38 +13,78 @@ import (
 
 // Updater 更新操作结构体
 type Updater struct {
+	ctx    context.Context
 	client *database.Client
 	schema *database.TableSchema
 	values map[string]any
 	where  []squirrel.Sqlizer
+
+	returning returning
+	version   any            // 乐观锁旧版本号，nil 时不检查
+	data      unsafe.Pointer // 更新成功后递增其中的版本号
+	err       error          // 解析模型的错误，执行时返回
 }
 
 // Update 更新
 func (m *Orm[T]) Update() (int64, error) {
-	return m.buildUpdater(true).Run()
+	return m.runUpdate(func() *Updater { return m.buildUpdater(true) })
 }
 
 // Save 保存
 func (m *Orm[T]) Save() (int64, error) {
-	return m.buildUpdater(false).Run()
+	return m.runUpdate(func() *Updater { return m.buildUpdater(false) })
 }
 
 // Updates 更新
 func (m *Orm[T]) Updates(cols map[string]any) (int64, error) {
-	schema := database.GetSchema(m.Data)
+	return m.runUpdate(func() *Updater { return m.buildUpdates(cols) })
+}
+
+// runUpdate 在更新钩子之间构造并执行 Updater，使 BeforeUpdate 的修改生效
+func (m *Orm[T]) runUpdate(build func() *Updater) (int64, error) {
+	before, after := updateHooks(m.Data)
+	return runHooks(m.context(), m.Client, before, func(ctx context.Context) (int64, error) {
+		return build().RunContext(ctx)
+	}, after)
+}
+
+func (m *Orm[T]) buildUpdates(cols map[string]any) *Updater {
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return &Updater{ctx: m.context(), err: err}
+	}
 	updater := &Updater{
+		ctx:    m.context(),
 		client: m.Client,
 		schema: schema,
-		values: cols,
-		where:  m.where,
+		values: make(map[string]any, len(cols)+2),
+		where:  append([]squirrel.Sqlizer{}, m.where...),
 	}
+	maps.Copy(updater.values, cols)
+	if field := schema.UpdatedAt; field != nil {
+		if _, ok := cols[field.ColumnName]; !ok {
+			if v, ok := timeValue(field, now()); ok {
+				updater.values[field.ColumnName] = v.Interface()
+			}
+		}
+	}
+	// 未携带旧版本号，仅递增不检查
+	if field := schema.Version; field != nil {
+		if _, ok := cols[field.ColumnName]; !ok {
+			updater.values[field.ColumnName] = incrVersion(field)
+		}
+	}
+	updater.returning = m.buildReturning(schema)
 
 	// 仅支持使用 m.Pk() 设置主键
-	if schema.PrimaryKey != nil && m.PkVal != nil {
-		updater.where = append(updater.where, squirrel.Eq{schema.PrimaryKey.ColumnName: m.PkVal})
+	if pk := m.pkWhere(schema, false); pk != nil {
+		updater.where = append(updater.where, pk)
+	}
+	if scope := m.scope(schema); scope != nil {
+		updater.where = append(updater.where, scope)
 	}
 
-	return updater.Run()
+	return updater
 }
 
 // Where 额外条件
@@ -52,41 +95,74 @@ func (u *Updater) Where(cond squirrel.Sqlizer) *Updater {
 
 // Run 执行更新
 func (u *Updater) Run() (int64, error) {
+	return u.RunContext(u.ctx)
+}
+
+// RunContext 使用指定上下文执行更新
+func (u *Updater) RunContext(ctx context.Context) (int64, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	ctx = database.WithOperation(ctx, u.schema.TableName, "orm.Update")
 	query := psql.Update(u.schema.TableName).SetMap(u.values).Where(squirrel.And(u.where))
-	return u.client.Update(query)
+	var n int64
+	var err error
+	if u.returning.enabled() {
+		n, err = u.returning.run(ctx, u.client, query.Suffix(u.returning.suffix()))
+	} else {
+		n, err = u.client.UpdateContext(ctx, query)
+	}
+	if err != nil || u.version == nil {
+		return n, err
+	}
+
+	if n == 0 {
+		return 0, &database.ErrStaleObject{Table: strings.ReplaceAll(u.schema.TableName, `"`, ""), Version: u.version}
+	}
+	// RETURNING 已写回新版本号时无需再递增
+	if u.data != nil && !slices.Contains(u.returning.fields, u.schema.Version) {
+		bumpVersion(u.schema.Version, u.data)
+	}
+	return n, nil
 }
 
 func (m *Orm[T]) buildUpdater(skipZero bool) *Updater {
-	schema := database.GetSchema(m.Data)
+	schema, err := database.LookupSchema(m.Data)
+	if err != nil {
+		return &Updater{ctx: m.context(), err: err}
+	}
 	updater := &Updater{
+		ctx:    m.context(),
 		client: m.Client,
 		schema: schema,
 		values: make(map[string]any),
 	}
+	updater.returning = m.buildReturning(schema)
 
-	val := reflect.ValueOf(m.Data).Elem()
-	ptr := val.UnsafeAddr()
+	ptr := unsafe.Pointer(m.Data)
+	touchUpdated(schema, ptr, now())
 
 	for _, field := range schema.Fields {
-		if field.PrimaryKey {
+		if !field.Updatable() {
 			continue
 		}
-
-		fieldVal := reflect.NewAt(field.GoType, unsafe.Pointer(ptr+field.Offset)).Elem().Interface()
-		if skipZero && database.IsZeroValue(fieldVal) {
+		if skipZero && field.IsZero(ptr) {
 			continue
 		}
-		updater.values[field.ColumnName] = fieldVal
+		updater.values[field.ColumnName] = writeValue(field, ptr)
 	}
 
-	if schema.PrimaryKey != nil {
-		var pkVal any
-		if m.PkVal == nil || database.IsZeroValue(m.PkVal) {
-			pkVal = *(*int64)(unsafe.Pointer(ptr + schema.PrimaryKey.Offset))
-		} else {
-			pkVal = m.PkVal
-		}
-		updater.where = append(updater.where, squirrel.Eq{schema.PrimaryKey.ColumnName: pkVal})
+	if pk := m.pkWhere(schema, true); pk != nil {
+		updater.where = append(updater.where, pk)
+	}
+	if field := schema.Version; field != nil {
+		updater.version = field.Value(ptr)
+		updater.data = ptr
+		updater.where = append(updater.where, squirrel.Eq{field.ColumnName: updater.version})
+		updater.values[field.ColumnName] = incrVersion(field)
+	}
+	if scope := m.scope(schema); scope != nil {
+		updater.where = append(updater.where, scope)
 	}
 
 	return updater
@@ -0,0 +1,262 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// errReturningSkipped 批量 DO NOTHING 或带 WHERE 的 DO UPDATE 时被跳过的行不返回，无法与输入行按位置对应
+var errReturningSkipped = errors.New("orm: RETURNING with DO NOTHING or DO UPDATE ... WHERE is not supported for bulk upsert")
+
+// Upserter INSERT ... ON CONFLICT 构造器
+type Upserter[T any] struct {
+	m    *Orm[T]
+	rows []T // 为 nil 时写入 m.Data
+
+	columns    []string // 冲突列
+	constraint string   // 冲突约束名，优先于 columns
+	doNothing  bool
+	update     []string // DO UPDATE 的列，为空时更新全部非冲突列
+	where      []sq.Sqlizer
+}
+
+// Upsert 插入 Data，冲突时按设置更新或忽略
+//
+// 默认冲突目标为主键，默认 DO UPDATE 全部非冲突列
+func (m *Orm[T]) Upsert() *Upserter[T] {
+	return &Upserter[T]{m: m}
+}
+
+// UpsertMany 批量 Upsert，按参数上限拆分，多批时在事务中执行
+func (m *Orm[T]) UpsertMany(rows []T) *Upserter[T] {
+	return &Upserter[T]{m: m, rows: rows}
+}
+
+// OnConflict 指定冲突列
+func (u *Upserter[T]) OnConflict(cols ...string) *Upserter[T] {
+	u.columns = cols
+	return u
+}
+
+// OnConstraint 指定冲突约束名
+func (u *Upserter[T]) OnConstraint(name string) *Upserter[T] {
+	u.constraint = name
+	return u
+}
+
+// DoNothing 冲突时忽略
+func (u *Upserter[T]) DoNothing() *Upserter[T] {
+	u.doNothing = true
+	return u
+}
+
+// DoUpdate 冲突时以 EXCLUDED 中的值更新 cols，未指定时更新全部非冲突列
+func (u *Upserter[T]) DoUpdate(cols ...string) *Upserter[T] {
+	u.doNothing = false
+	u.update = cols
+	return u
+}
+
+// Where DO UPDATE 的附加条件，已有行以表名引用，新行以 EXCLUDED 引用
+func (u *Upserter[T]) Where(cond sq.Sqlizer) *Upserter[T] {
+	u.where = append(u.where, cond)
+	return u
+}
+
+// Run 执行 Upsert
+func (u *Upserter[T]) Run() (int64, error) {
+	return u.RunContext(u.m.context())
+}
+
+// RunContext 使用指定上下文执行 Upsert
+//
+// 插入钩子对每条记录调用，冲突后更新或被忽略的记录同样调用
+func (u *Upserter[T]) RunContext(ctx context.Context) (int64, error) {
+	rows := u.rows
+	if rows == nil {
+		// 与 Data 共享内存，RETURNING 直接写回 Data
+		rows = unsafe.Slice(u.m.Data, 1)
+	}
+	before := eachHook[T, BeforeCreator](rows, BeforeCreator.BeforeCreate)
+	after := eachHook[T, AfterCreator](rows, AfterCreator.AfterCreate)
+	return runHooks(ctx, u.m.Client, before, func(ctx context.Context) (int64, error) {
+		return u.run(ctx, rows)
+	}, after)
+}
+
+// run 执行 Upsert，rows 为写入的记录
+func (u *Upserter[T]) run(ctx context.Context, rows []T) (int64, error) {
+	schema, err := database.LookupSchema(u.m.Data)
+	if err != nil {
+		return 0, err
+	}
+	ctx = database.WithOperation(ctx, schema.TableName, "orm.Upsert")
+
+	fields := u.fields(schema)
+	suffix, err := u.suffix(schema, fields)
+	if err != nil {
+		return 0, err
+	}
+	ret := u.m.buildReturning(schema)
+	t := now()
+	for i := range rows {
+		base := unsafe.Pointer(&rows[i])
+		touchCreated(schema, base, t)
+		touchUpdated(schema, base, t)
+	}
+
+	if u.rows == nil {
+		return insertBatch(ctx, u.m.Client, schema, fields, rows, suffix, ret)
+	}
+	if len(u.rows) == 0 {
+		return 0, nil
+	}
+	if ret.enabled() && (u.doNothing || len(u.where) > 0) {
+		return 0, errReturningSkipped
+	}
+
+	size, err := batchSize(len(fields), suffix)
+	if err != nil {
+		return 0, err
+	}
+	if len(u.rows) <= size {
+		return insertBatch(ctx, u.m.Client, schema, fields, u.rows, suffix, ret)
+	}
+	var total int64
+	err = u.m.Client.Tx(ctx, database.TxOptions{}, func(tx *database.Tx) error {
+		for start := 0; start < len(u.rows); start += size {
+			end := min(start+size, len(u.rows))
+			n, err := insertBatch(tx.Context(), tx.Client, schema, fields, u.rows[start:end], suffix, ret)
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// batchSize 返回每批的行数，ON CONFLICT ... WHERE 的参数在每批中重复出现，需从参数上限中扣除
+func batchSize(columns int, suffix sq.Sqlizer) (int, error) {
+	_, args, err := suffix.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	return (maxParams - len(args)) / columns, nil
+}
+
+// fields 返回写入的字段：同插入规则，非自增主键始终写入，自增列为冲突列时写入，零值时写入 DEFAULT
+func (u *Upserter[T]) fields(schema *database.TableSchema) []*database.FieldSchema {
+	conflict := u.conflictColumns(schema)
+	fields := make([]*database.FieldSchema, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.Insertable() || field.PrimaryKey && !field.AutoIncr ||
+			field.AutoIncr && slices.Contains(conflict, field.ColumnName) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// conflictColumns 返回冲突列，未指定列与约束时为主键
+func (u *Upserter[T]) conflictColumns(schema *database.TableSchema) []string {
+	if len(u.columns) > 0 || u.constraint != "" {
+		return u.columns
+	}
+	cols := make([]string, len(schema.PrimaryKeys))
+	for i, field := range schema.PrimaryKeys {
+		cols[i] = field.ColumnName
+	}
+	return cols
+}
+
+// conflictTable 返回 DO UPDATE 中引用已有行的表名，不带 schema 前缀
+func conflictTable(schema *database.TableSchema) string {
+	name := schema.TableName
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// suffix 构造 ON CONFLICT 子句
+func (u *Upserter[T]) suffix(schema *database.TableSchema, fields []*database.FieldSchema) (sq.Sqlizer, error) {
+	var sb strings.Builder
+	sb.WriteString("ON CONFLICT")
+
+	conflict := u.conflictColumns(schema)
+	switch {
+	case u.constraint != "":
+		sb.WriteString(" ON CONSTRAINT ")
+		sb.WriteString(u.constraint)
+	case len(conflict) > 0:
+		sb.WriteString(" (")
+		sb.WriteString(strings.Join(conflict, ", "))
+		sb.WriteString(")")
+	case !u.doNothing:
+		return nil, fmt.Errorf("orm: upsert on %s requires a conflict target", schema.TableName)
+	}
+
+	if u.doNothing {
+		sb.WriteString(" DO NOTHING")
+		return sq.Expr(sb.String()), nil
+	}
+
+	update := u.update
+	if len(update) == 0 {
+		for _, field := range fields {
+			if !field.Updatable() || slices.Contains(conflict, field.ColumnName) {
+				continue
+			}
+			update = append(update, field.ColumnName)
+		}
+	}
+	if len(update) == 0 {
+		return nil, fmt.Errorf("orm: upsert on %s has no columns to update", schema.TableName)
+	}
+	for i, col := range update {
+		if _, ok := schema.ColumnToField[col]; !ok {
+			return nil, fmt.Errorf("orm: unknown upsert column %q on %s", col, schema.TableName)
+		}
+		if i == 0 {
+			sb.WriteString(" DO UPDATE SET ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(col)
+		sb.WriteString(" = EXCLUDED.")
+		sb.WriteString(col)
+	}
+	// 更新已有行时递增版本号，未显式更新版本列时生效
+	if field := schema.Version; field != nil && !slices.Contains(update, field.ColumnName) {
+		sb.WriteString(", ")
+		sb.WriteString(field.ColumnName)
+		sb.WriteString(" = ")
+		sb.WriteString(conflictTable(schema))
+		sb.WriteString(".")
+		sb.WriteString(field.ColumnName)
+		sb.WriteString(" + 1")
+	}
+
+	if len(u.where) == 0 {
+		return sq.Expr(sb.String()), nil
+	}
+	where, args, err := sq.And(u.where).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	sb.WriteString(" WHERE ")
+	sb.WriteString(where)
+	return sq.Expr(sb.String(), args...), nil
+}
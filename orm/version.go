@@ -0,0 +1,25 @@
+package orm
+
+import (
+	"reflect"
+	"unsafe"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/skadiD/database"
+)
+
+// incrVersion 版本号自增表达式
+func incrVersion(field *database.FieldSchema) sq.Sqlizer {
+	return sq.Expr(field.ColumnName + " + 1")
+}
+
+// bumpVersion 递增 base 所指结构体中的版本号，仅支持整数字段
+func bumpVersion(field *database.FieldSchema, base unsafe.Pointer) {
+	v := reflect.NewAt(field.GoType, unsafe.Add(base, field.Offset)).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	}
+}
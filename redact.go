@@ -0,0 +1,344 @@
+package database
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedactMode 敏感参数在错误信息与日志中的处理方式
+type RedactMode int
+
+const (
+	// RedactMask 替换为 ***
+	RedactMask RedactMode = iota
+	// RedactHash 替换为值的 SHA-256 前缀，便于在不泄露原值的情况下比对
+	RedactHash
+	// RedactDrop 存在敏感参数时不输出任何参数
+	RedactDrop
+)
+
+// RedactionPolicy 参数脱敏策略
+type RedactionPolicy struct {
+	Mode RedactMode
+	// AllArgs 将全部参数视为敏感参数
+	AllArgs bool
+}
+
+// droppedArgs RedactDrop 时代替参数输出的内容
+const droppedArgs = "<dropped>"
+
+var (
+	redactMu     sync.RWMutex
+	redactPolicy RedactionPolicy
+	// sensitiveColumns 对所有表生效的敏感列
+	sensitiveColumns = map[string]struct{}{}
+	// tableSensitiveColumns 表名 -> 模型中带 sensitive 标签的列，仅在语句涉及该表时生效
+	tableSensitiveColumns = map[string]map[string]struct{}{}
+	// sensitiveArgCache 表名 + SQL -> 敏感参数下标，敏感列变化时清空
+	sensitiveArgCache = newArgCache(sensitiveArgCacheSize)
+)
+
+// sensitiveArgCacheSize 敏感参数下标缓存的容量
+const sensitiveArgCacheSize = 1024
+
+// SetRedactionPolicy 设置全局脱敏策略，作用于本库产生的全部错误信息与日志
+func SetRedactionPolicy(p RedactionPolicy) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactPolicy = p
+}
+
+// RegisterSensitiveColumns 将列名登记为对所有表生效的敏感列
+//
+// 登记后任意表中同名列的参数都会被脱敏；带 sensitive 标签的模型字段在注册模型时按表登记，
+// 只在语句涉及该表时生效，无需再调用本函数
+func RegisterSensitiveColumns(columns ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, col := range columns {
+		sensitiveColumns[strings.ToLower(col)] = struct{}{}
+	}
+	sensitiveArgCache.Clear()
+}
+
+// registerTableSensitiveColumns 登记 table 的敏感列
+func registerTableSensitiveColumns(table string, columns ...string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	table = normalizeTable(table)
+	cols := tableSensitiveColumns[table]
+	if cols == nil {
+		cols = map[string]struct{}{}
+		tableSensitiveColumns[table] = cols
+	}
+	for _, col := range columns {
+		cols[strings.ToLower(col)] = struct{}{}
+	}
+	sensitiveArgCache.Clear()
+}
+
+// RedactArgs 按全局策略返回脱敏后的参数；RedactDrop 且存在敏感参数时返回 nil
+//
+// 敏感参数通过解析 SQL 中与敏感列关联的占位符确定，支持 INSERT 列表（含 CTE 中的 INSERT）、SET、
+// WHERE 比较、IN/ANY 与行值比较等本库生成的语句形式；模型的敏感列仅在 SQL 引用了该表时生效
+func RedactArgs(sql string, args []any) []any {
+	return redactArgs("", sql, args)
+}
+
+// redactArgs 同 RedactArgs，table 为 WithOperation 标记的语句所属表，其敏感列始终生效
+func redactArgs(table, sql string, args []any) []any {
+	if len(args) == 0 {
+		return args
+	}
+	redactMu.RLock()
+	policy := redactPolicy
+	redactMu.RUnlock()
+
+	var sensitive map[int]bool
+	if !policy.AllArgs {
+		if sensitive = sensitiveArgs(table, sql); len(sensitive) == 0 {
+			return args
+		}
+	}
+	if policy.Mode == RedactDrop {
+		return nil
+	}
+
+	out := make([]any, len(args))
+	for i, arg := range args {
+		if !policy.AllArgs && !sensitive[i+1] {
+			out[i] = arg
+			continue
+		}
+		out[i] = redactValue(policy.Mode, arg)
+	}
+	return out
+}
+
+// redactedArgs 返回用于日志的参数
+func redactedArgs(table, sql string, args []any) any {
+	out := redactArgs(table, sql, args)
+	if out == nil && args != nil {
+		return droppedArgs
+	}
+	return out
+}
+
+func redactValue(mode RedactMode, v any) string {
+	if mode == RedactHash {
+		sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	return "***"
+}
+
+var (
+	identPattern = `(?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?`
+	opPattern    = `(?:=|<>|!=|<=|>=|<|>|(?i:NOT\s+)?(?i:I?LIKE|IN)|(?i:IS\s+(?:NOT\s+)?DISTINCT\s+FROM))`
+	// col op $1 / col IN ($1, $2) / col = ANY($1)
+	compareRegex = regexp.MustCompile(`(` + identPattern + `)\s*` + opPattern + `\s*(?i:ANY\s*)?\(?\s*((?:\$\d+\s*,?\s*)+)\)?`)
+	// (a, b) op ($1, $2)
+	rowCompareRegex = regexp.MustCompile(`\(((?:\s*` + identPattern + `\s*,?)+)\)\s*` + opPattern + `\s*\(((?:\s*\$\d+\s*,?)+)\)`)
+	// INSERT INTO t (a, b) VALUES ...，可位于 CTE 中
+	insertRegex      = regexp.MustCompile(`(?is)\bINSERT\s+INTO\s+\S+?\s*\(([^)]*)\)\s*VALUES\s*(.*)$`)
+	placeholderRegex = regexp.MustCompile(`\$(\d+)`)
+	// tableRefRegex 语句引用的表
+	tableRefRegex = regexp.MustCompile(`(?i)\b(?:FROM|JOIN|INTO|UPDATE)\s+(` + identPattern + `)`)
+)
+
+// sensitiveArgs 返回 SQL 中对应敏感列的占位符序号（从 1 开始）
+//
+// 未登记敏感列时直接返回 nil，不写入缓存
+func sensitiveArgs(table, sql string) map[int]bool {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	columns := statementSensitiveColumns(table, sql)
+	if len(columns) == 0 {
+		return nil
+	}
+	key := table + "\x00" + sql
+	if cached, ok := sensitiveArgCache.Get(key); ok {
+		return cached
+	}
+
+	result := map[int]bool{}
+	mark := func(col string, params string) {
+		if _, ok := columns[normalizeIdent(col)]; !ok {
+			return
+		}
+		for _, m := range placeholderRegex.FindAllStringSubmatch(params, -1) {
+			n, _ := strconv.Atoi(m[1])
+			result[n] = true
+		}
+	}
+
+	if m := insertRegex.FindStringSubmatch(sql); m != nil {
+		cols := strings.Split(m[1], ",")
+		for _, tuple := range splitTuples(m[2]) {
+			for i, item := range tuple {
+				if i < len(cols) {
+					mark(cols[i], item)
+				}
+			}
+		}
+	}
+	for _, m := range rowCompareRegex.FindAllStringSubmatch(sql, -1) {
+		cols, params := strings.Split(m[1], ","), strings.Split(m[2], ",")
+		for i := range cols {
+			if i < len(params) {
+				mark(cols[i], params[i])
+			}
+		}
+	}
+	for _, m := range compareRegex.FindAllStringSubmatch(sql, -1) {
+		mark(m[1], m[2])
+	}
+
+	sensitiveArgCache.Add(key, result)
+	return result
+}
+
+// argCache 有容量上限的 LRU 缓存，可变长度的 IN 列表与多行 INSERT 会产生大量不同的 SQL
+type argCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type argCacheEntry struct {
+	key   string
+	value map[int]bool
+}
+
+func newArgCache(size int) *argCache {
+	return &argCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get 返回缓存的值并将其标记为最近使用
+func (c *argCache) Get(key string) (map[int]bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*argCacheEntry).value, true
+	}
+	return nil, false
+}
+
+// Add 写入缓存，超出容量时淘汰最久未使用的项
+func (c *argCache) Add(key string, value map[int]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*argCacheEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&argCacheEntry{key: key, value: value})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*argCacheEntry).key)
+	}
+}
+
+// Len 返回缓存项数
+func (c *argCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Clear 清空缓存
+func (c *argCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	clear(c.items)
+}
+
+// statementSensitiveColumns 语句适用的敏感列：全局敏感列与 table 及 SQL 引用的表的敏感列，调用方持有 redactMu
+func statementSensitiveColumns(table, sql string) map[string]struct{} {
+	if len(tableSensitiveColumns) == 0 {
+		return sensitiveColumns
+	}
+	tables := []string{normalizeTable(table)}
+	for _, m := range tableRefRegex.FindAllStringSubmatch(sql, -1) {
+		tables = append(tables, normalizeTable(m[1]))
+	}
+
+	var columns map[string]struct{}
+	for _, t := range tables {
+		cols := tableSensitiveColumns[t]
+		if len(cols) == 0 {
+			continue
+		}
+		if columns == nil {
+			columns = maps.Clone(sensitiveColumns)
+		}
+		maps.Copy(columns, cols)
+	}
+	if columns == nil {
+		return sensitiveColumns
+	}
+	return columns
+}
+
+// normalizeTable 去除引号并转为小写，保留 schema 前缀
+func normalizeTable(table string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(table), `"`, ""))
+}
+
+// normalizeIdent 去除表前缀与引号并转为小写
+func normalizeIdent(ident string) string {
+	ident = strings.TrimSpace(ident)
+	if i := strings.LastIndex(ident, "."); i >= 0 {
+		ident = ident[i+1:]
+	}
+	return strings.ToLower(strings.Trim(ident, `"`))
+}
+
+// splitTuples 将 VALUES 之后的 (a, b), (c, d) 拆分为各元组的顶层元素
+func splitTuples(values string) [][]string {
+	var (
+		tuples [][]string
+		tuple  []string
+		depth  int
+		start  int
+	)
+	for i, r := range values {
+		switch r {
+		case '(':
+			depth++
+			if depth == 1 {
+				tuple, start = nil, i+1
+			}
+		case ')':
+			if depth == 0 {
+				return tuples
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			tuples = append(tuples, append(tuple, values[start:i]))
+			// 元组之间以逗号分隔，其后为 ON CONFLICT、RETURNING 等子句
+			if rest := strings.TrimSpace(values[i+1:]); !strings.HasPrefix(rest, ",") {
+				return tuples
+			}
+		case ',':
+			if depth == 1 {
+				tuple, start = append(tuple, values[start:i]), i+1
+			}
+		}
+	}
+	return tuples
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// resetRedaction 测试结束时恢复全局敏感列与脱敏策略
+func resetRedaction(t *testing.T) {
+	redactMu.RLock()
+	columns, policy := maps.Clone(sensitiveColumns), redactPolicy
+	redactMu.RUnlock()
+	t.Cleanup(func() {
+		redactMu.Lock()
+		sensitiveColumns, redactPolicy = columns, policy
+		redactMu.Unlock()
+		sensitiveArgCache.Clear()
+	})
+}
+
+func TestRedactArgs(t *testing.T) {
+	resetRedaction(t)
+	RegisterSensitiveColumns("password", "phone")
+
+	cases := []struct {
+		sql  string
+		args []any
+		want []any
+	}{
+		{
+			`INSERT INTO "users" (name,password,phone) VALUES ($1,$2,$3),($4,$5,$6) RETURNING id`,
+			[]any{"a", "p1", "13800000000", "b", "p2", "13900000000"},
+			[]any{"a", "***", "***", "b", "***", "***"},
+		},
+		{
+			`UPDATE "users" SET name = $1, password = $2 WHERE id = $3`,
+			[]any{"a", "secret", 1},
+			[]any{"a", "***", 1},
+		},
+		{
+			`SELECT * FROM users WHERE "users"."phone" IN ($1,$2) AND age > $3`,
+			[]any{"138", "139", 18},
+			[]any{"***", "***", 18},
+		},
+		{
+			`SELECT * FROM users WHERE (phone, id) > ($1, $2)`,
+			[]any{"138", 7},
+			[]any{"***", 7},
+		},
+		{
+			`WITH n AS (INSERT INTO users (name, password) VALUES ($1, $2) RETURNING id) SELECT id FROM n WHERE id > $3`,
+			[]any{"a", "secret", 0},
+			[]any{"a", "***", 0},
+		},
+		{
+			`SELECT * FROM users WHERE name = $1`,
+			[]any{"a"},
+			[]any{"a"},
+		},
+	}
+	for _, c := range cases {
+		if got := RedactArgs(c.sql, c.args); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("RedactArgs(%q) = %v, want %v", c.sql, got, c.want)
+		}
+	}
+
+	SetRedactionPolicy(RedactionPolicy{Mode: RedactHash})
+	got := RedactArgs(`UPDATE users SET password = $1`, []any{"secret"})
+	if s, _ := got[0].(string); !strings.HasPrefix(s, "sha256:") || strings.Contains(s, "secret") {
+		t.Errorf("expected hashed value, got %v", got)
+	}
+
+	SetRedactionPolicy(RedactionPolicy{Mode: RedactDrop})
+	if got := redactedArgs("", `UPDATE users SET password = $1`, []any{"secret"}); got != droppedArgs {
+		t.Errorf("expected args to be dropped, got %v", got)
+	}
+
+	SetRedactionPolicy(RedactionPolicy{Mode: RedactMask, AllArgs: true})
+	if got := RedactArgs(`SELECT * FROM users WHERE name = $1`, []any{"a"}); got[0] != "***" {
+		t.Errorf("expected all args masked, got %v", got)
+	}
+}
+
+func TestSensitiveTagScopedToTable(t *testing.T) {
+	resetRedaction(t)
+	type member struct {
+		ID    int64  `orm:"id,pk"`
+		Token string `orm:"token,sensitive"`
+	}
+	_ = RegisterModel[member]("member")
+
+	if got := RedactArgs(`UPDATE "member" SET token = $1 WHERE id = $2`, []any{"t", 1}); got[0] != "***" {
+		t.Errorf("expected member.token to be redacted, got %v", got)
+	}
+	if got := RedactArgs(`UPDATE device SET token = $1 WHERE id = $2`, []any{"t", 1}); got[0] != "t" {
+		t.Errorf("token on another table should not be redacted, got %v", got)
+	}
+	// 由 WithOperation 标记的表确定，SQL 中未出现表名时也生效
+	if got := redactArgs("member", `SELECT 1 WHERE token = $1`, []any{"t"}); got[0] != "***" {
+		t.Errorf("expected operation table columns to be redacted, got %v", got)
+	}
+}
+
+func TestHookReceivesRedactedArgs(t *testing.T) {
+	resetRedaction(t)
+	RegisterSensitiveColumns("password")
+	var calls []string
+	hook := &recordHook{name: "a", calls: &calls}
+	tracer := queryTracer{c: (&Client{}).SetLogger(NopLogger).AddQueryHook(hook)}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "UPDATE users SET password = $1 WHERE id = $2",
+		Args: []any{"secret", 1},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if got := hook.events[0].Args; !reflect.DeepEqual(got, []any{"***", 1}) {
+		t.Errorf("hook args = %v", got)
+	}
+}
+
+func TestSensitiveTagRegistersColumn(t *testing.T) {
+	type account struct {
+		ID     int64  `orm:"id,pk"`
+		Secret string `orm:"api_secret,sensitive"`
+	}
+	_ = RegisterModel[account]("account")
+	err := pool.queryErr(context.Background(), errors.New("boom"), "", "test", `UPDATE account SET api_secret = $1 WHERE id = $2`, []any{"top-secret", 1})
+	if strings.Contains(err.Error(), "top-secret") {
+		t.Errorf("sensitive value leaked into error: %v", err)
+	}
+}
+
+func TestSensitiveArgCacheBounded(t *testing.T) {
+	resetRedaction(t)
+	sensitiveArgCache.Clear()
+	if sensitiveArgs("", `SELECT * FROM nobody WHERE name = $1`) != nil || sensitiveArgCache.Len() != 0 {
+		t.Fatal("statements without sensitive columns should not be cached")
+	}
+
+	RegisterSensitiveColumns("password")
+	for i := range sensitiveArgCacheSize + 10 {
+		sensitiveArgs("", fmt.Sprintf(`SELECT * FROM users WHERE password = $1 AND id = %d`, i))
+	}
+	if n := sensitiveArgCache.Len(); n != sensitiveArgCacheSize {
+		t.Errorf("cache size = %d, want %d", n, sensitiveArgCacheSize)
+	}
+}
+
+func TestRegisterSensitive(t *testing.T) {
+	type credential struct {
+		ID    int64  `orm:"id,pk"`
+		Token string `orm:"refresh_token,sensitive"`
+	}
+	if err := RegisterSensitive[credential](); err != nil {
+		t.Fatal(err)
+	}
+	if got := RedactArgs(`UPDATE credential SET refresh_token = $1`, []any{"t"}); got[0] != "***" {
+		t.Errorf("expected refresh_token to be redacted, got %v", got)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RelationKind 关联类型
+type RelationKind uint8
+
+const (
+	BelongsTo  RelationKind = iota // 外键在本表，指向目标表
+	HasOne                         // 外键在目标表，指向本表，至多一条
+	HasMany                        // 外键在目标表，指向本表
+	ManyToMany                     // 通过中间表关联
+)
+
+// RelationSchema 关联元数据，由 rel 标签声明，如
+//
+//	Author *User   `rel:"belongs_to,fk=author_id"`
+//	Orders []Order `rel:"has_many,fk=user_id"`
+//	Tags   []Tag   `rel:"many2many,join=post_tags,fk=post_id,join_ref=tag_id"`
+type RelationSchema struct {
+	Name   string       // Go字段名
+	Kind   RelationKind // 关联类型
+	Offset uintptr      // 字段偏移量
+	GoType reflect.Type // 字段类型
+	Target reflect.Type // 关联模型的结构体类型
+	Ptr    bool         // 字段（或切片元素）是否为指针
+
+	// ForeignKey belongs_to 为本表外键列，has_one/has_many 为目标表外键列，many2many 为中间表中指向本表的列
+	ForeignKey string
+	// References belongs_to 为目标表被引用列，has_one/has_many/many2many 为本表被引用列，为空时使用主键
+	References string
+	// JoinTable many2many 中间表
+	JoinTable string
+	// JoinReferences many2many 中间表中指向目标表的列
+	JoinReferences string
+}
+
+// parseRelation 解析 rel 标签
+func parseRelation(owner reflect.Type, field reflect.StructField, tag string) (*RelationSchema, error) {
+	parts := strings.Split(tag, ",")
+	rel := &RelationSchema{
+		Name:   field.Name,
+		Offset: field.Offset,
+		GoType: field.Type,
+	}
+
+	switch parts[0] {
+	case "belongs_to":
+		rel.Kind = BelongsTo
+	case "has_one":
+		rel.Kind = HasOne
+	case "has_many":
+		rel.Kind = HasMany
+	case "many2many":
+		rel.Kind = ManyToMany
+	default:
+		return nil, fmt.Errorf("database: unknown relation %q on %s.%s", parts[0], owner, field.Name)
+	}
+
+	for _, opt := range parts[1:] {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "fk":
+			rel.ForeignKey = value
+		case "ref":
+			rel.References = value
+		case "join":
+			rel.JoinTable = value
+		case "join_ref":
+			rel.JoinReferences = value
+		default:
+			return nil, fmt.Errorf("database: unknown relation option %q on %s.%s", key, owner, field.Name)
+		}
+	}
+
+	// 校验字段类型：单条关联为 S 或 *S，多条关联为 []S 或 []*S
+	typ := field.Type
+	if rel.Kind == HasMany || rel.Kind == ManyToMany {
+		if typ.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("database: relation %s.%s must be a slice", owner, field.Name)
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Ptr {
+		rel.Ptr = true
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("database: relation %s.%s must reference a struct", owner, field.Name)
+	}
+	rel.Target = typ
+
+	// 默认外键：belongs_to 为 字段名_id，其余为 本表类型名_id
+	if rel.ForeignKey == "" {
+		if rel.Kind == BelongsTo {
+			rel.ForeignKey = struct2name("."+field.Name) + "_id"
+		} else {
+			rel.ForeignKey = struct2name(owner.String()) + "_id"
+		}
+	}
+	if rel.Kind == ManyToMany {
+		if rel.JoinTable == "" {
+			return nil, fmt.Errorf("database: relation %s.%s requires join table", owner, field.Name)
+		}
+		if rel.JoinReferences == "" {
+			rel.JoinReferences = struct2name(typ.String()) + "_id"
+		}
+	}
+	return rel, nil
+}
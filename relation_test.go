@@ -0,0 +1,60 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+type relAuthor struct {
+	ID int64 `orm:"id,pk"`
+}
+
+type relTag struct {
+	ID int64 `orm:"id,pk"`
+}
+
+type relPost struct {
+	ID       int64      `orm:"id,pk"`
+	AuthorID int64      `orm:"author_id"`
+	Author   *relAuthor `rel:"belongs_to"`
+	Tags     []relTag   `rel:"many2many,join=post_tags,fk=post_id,join_ref=tag_id"`
+	Comments []*relPost `rel:"has_many,fk=parent_id,ref=id"`
+}
+
+func TestParseRelation(t *testing.T) {
+	schema, err := buildSchema(reflect.TypeFor[relPost](), "post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Fields) != 2 || len(schema.Relations) != 3 {
+		t.Fatalf("fields = %d, relations = %d", len(schema.Fields), len(schema.Relations))
+	}
+
+	author := schema.Relations["Author"]
+	if author.Kind != BelongsTo || author.ForeignKey != "author_id" || !author.Ptr || author.Target != reflect.TypeFor[relAuthor]() {
+		t.Fatalf("Author = %+v", author)
+	}
+	tags := schema.Relations["Tags"]
+	if tags.Kind != ManyToMany || tags.JoinTable != "post_tags" || tags.ForeignKey != "post_id" || tags.JoinReferences != "tag_id" || tags.Ptr {
+		t.Fatalf("Tags = %+v", tags)
+	}
+	comments := schema.Relations["Comments"]
+	if comments.Kind != HasMany || comments.ForeignKey != "parent_id" || comments.References != "id" || !comments.Ptr {
+		t.Fatalf("Comments = %+v", comments)
+	}
+
+	type badKind struct {
+		X []relTag `rel:"owns"`
+	}
+	type badType struct {
+		X relTag `rel:"has_many"`
+	}
+	type noJoin struct {
+		X []relTag `rel:"many2many"`
+	}
+	for _, typ := range []reflect.Type{reflect.TypeFor[badKind](), reflect.TypeFor[badType](), reflect.TypeFor[noJoin]()} {
+		if _, err = buildSchema(typ, "bad"); err == nil {
+			t.Fatalf("expected error for %s", typ)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplicaBalance 只读副本负载均衡策略
+type ReplicaBalance int
+
+const (
+	// RoundRobin 依次使用各健康副本
+	RoundRobin ReplicaBalance = iota
+	// LeastConn 使用已借出连接最少的健康副本
+	LeastConn
+)
+
+// replicaPingTimeout 单次副本健康检查的超时时间
+const replicaPingTimeout = 3 * time.Second
+
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+type replicaSet struct {
+	replicas []*replica
+	balance  ReplicaBalance
+	next     atomic.Uint64
+	stop     context.CancelFunc
+}
+
+type readKey struct{}
+
+type primaryKey struct{}
+
+// UsePrimary 返回强制在主库执行的上下文，用于写入后立即读取（read-your-writes）
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// withRead 标记 ctx 中的语句为只读查询，可路由到副本
+func withRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readKey{}, true)
+}
+
+// readConn 返回执行查询的对象：只读查询在没有进行中的事务且未强制主库时路由到健康副本
+func (c *Client) readConn(ctx context.Context) (executor, error) {
+	db, err := c.conn(ctx)
+	if err != nil || db != c.Client || c.replicas == nil {
+		return db, err
+	}
+	if read, _ := ctx.Value(readKey{}).(bool); !read {
+		return db, nil
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return db, nil
+	}
+	if r := c.replicas.pick(); r != nil {
+		return r.pool, nil
+	}
+	return db, nil
+}
+
+// newReplicaSet 创建副本连接池并启动健康检查
+func (c *Client) newReplicaSet(ctx context.Context) (*replicaSet, error) {
+	set := &replicaSet{balance: c.opts.ReplicaBalance}
+	for i, connString := range c.opts.ReplicaConnStrings {
+		config, err := c.poolConfig(connString)
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("database: replica %d: %w", i, err)
+		}
+		r := &replica{}
+		config.ConnConfig.Tracer = queryTracer{c: c, replica: r}
+		if r.pool, err = pgxpool.NewWithConfig(ctx, config); err != nil {
+			set.close()
+			return nil, fmt.Errorf("database: replica %d: create pool: %w", i, err)
+		}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+
+	interval := c.opts.ReplicaHealthCheckInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	checkCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	set.stop = stop
+	go set.healthCheck(checkCtx, c, interval)
+	return set, nil
+}
+
+// pick 按负载均衡策略选择健康副本，全部不可用时返回 nil
+func (s *replicaSet) pick() *replica {
+	n := len(s.replicas)
+	if s.balance == LeastConn {
+		var best *replica
+		var bestConns int32
+		for _, r := range s.replicas {
+			if !r.healthy.Load() {
+				continue
+			}
+			if conns := r.pool.Stat().AcquiredConns(); best == nil || conns < bestConns {
+				best, bestConns = r, conns
+			}
+		}
+		return best
+	}
+
+	start := s.next.Add(1)
+	for i := 0; i < n; i++ {
+		if r := s.replicas[(start+uint64(i))%uint64(n)]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// healthCheck 定期检查副本，失败的副本暂停使用直至恢复
+func (s *replicaSet) healthCheck(ctx context.Context, c *Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for i, r := range s.replicas {
+			pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+			err := r.pool.Ping(pingCtx)
+			cancel()
+			if healthy := err == nil; r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					c.log(ctx, slog.LevelInfo, "database: replica recovered", slog.Int("replica", i))
+				} else {
+					c.log(ctx, slog.LevelWarn, "database: replica ejected", slog.Int("replica", i), slog.Any(LogKeyError, err))
+				}
+			}
+		}
+	}
+}
+
+func (s *replicaSet) close() {
+	if s == nil {
+		return
+	}
+	if s.stop != nil {
+		s.stop()
+	}
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/skadiD/database/scan/custom_row2struct"
 )
 
 func RowToStructByName[T any](row pgx.CollectableRow) (T, error) {
@@ -132,6 +133,9 @@ func computeNamedStructFields(
 				missingField = missingSubField
 			}
 		} else {
+			if dbTag == "" {
+				dbTag, _ = custom_row2struct.OrmColumnName(sf)
+			}
 			if dbTag == "-" {
 				// Field is ignored, skip it.
 				continue
@@ -155,6 +159,7 @@ func computeNamedStructFields(
 
 	return fields, missingField
 }
+
 func joinFieldNames(fldDescs []pgconn.FieldDescription) string {
 	switch len(fldDescs) {
 	case 0:
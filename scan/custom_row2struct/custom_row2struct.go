@@ -252,11 +252,13 @@ func computeNamedStructFields(
 				// Field is ignored, skip it.
 				continue
 			}
-			colName := dbTag
+			colName, normalize := dbTag, false
 			if !dbTagPresent {
-				colName = sf.Name
+				if colName, normalize = OrmColumnName(sf); colName == "-" {
+					continue
+				}
 			}
-			fpos := fieldPosByName(fldDescs, colName, !dbTagPresent)
+			fpos := fieldPosByName(fldDescs, colName, normalize)
 			if fpos == -1 {
 				if missingField == "" {
 					missingField = colName
@@ -275,6 +277,22 @@ func computeNamedStructFields(
 
 const structTagKey = "db"
 
+// OrmColumnName 无 db 标签时按 orm 标签取列名，关联字段与 orm:"-" 返回 "-"；
+// 未指定列名时返回字段名，fuzzy 为 true 表示应忽略大小写与下划线匹配
+func OrmColumnName(sf reflect.StructField) (name string, fuzzy bool) {
+	if _, ok := sf.Tag.Lookup("rel"); ok {
+		return "-", false
+	}
+	tag := sf.Tag.Get("orm")
+	if tag == "-" {
+		return tag, false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return sf.Name, true
+}
+
 func fieldPosByName(fldDescs []pgconn.FieldDescription, field string, normalize bool) (i int) {
 	i = -1
 
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type scanUser struct {
+	ID     int64       `orm:"id,pk"`
+	Name   string      `orm:"name"`
+	Orders []scanOrder `rel:"has_many"`
+}
+
+type scanOrder struct {
+	ID     int64 `orm:"id,pk"`
+	UserID int64 `orm:"user_id"`
+	Memo   string
+}
+
+type scanUserOrder struct {
+	scanUser  `db:"user"`
+	scanOrder `db:"order"`
+}
+
+func TestLookupNamedStructFields_OrmTags(t *testing.T) {
+	names := []string{"user_id", "user_name", "order_id", "order_user_id", "order_Memo"}
+	descs := make([]pgconn.FieldDescription, len(names))
+	for i, name := range names {
+		descs[i] = pgconn.FieldDescription{Name: name}
+	}
+
+	fields, err := lookupNamedStructFields(reflect.TypeFor[scanUserOrder](), descs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields.missingField != "" {
+		t.Fatalf("missing field %s", fields.missingField)
+	}
+	want := [][]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {1, 2}}
+	for i, f := range fields.fields {
+		if !reflect.DeepEqual(f.path, want[i]) {
+			t.Fatalf("%s -> %v, want %v", names[i], f.path, want[i])
+		}
+	}
+}
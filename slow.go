@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// explainTimeout 慢查询 EXPLAIN 的超时时间
+const explainTimeout = 5 * time.Second
+
+// maxConcurrentExplains 同时进行的慢查询 EXPLAIN 上限，已满时不再获取执行计划
+const maxConcurrentExplains = 2
+
+// 慢查询日志字段名
+const (
+	LogKeyCaller = "caller"
+	LogKeyPlan   = "plan"
+)
+
+// explainKey 标记 EXPLAIN 语句本身为内部语句，不经过钩子、追踪与日志
+type explainKey struct{}
+
+// isInternalQuery 判断语句是否为本库内部发起的语句
+func isInternalQuery(ctx context.Context) bool {
+	explaining, _ := ctx.Value(explainKey{}).(bool)
+	return explaining
+}
+
+// isSlowQuery 判断语句是否应记录为慢查询
+func (c *Client) isSlowQuery(event *QueryEvent) bool {
+	threshold := c.opts.SlowQueryThreshold
+	return threshold > 0 && event.Duration >= threshold
+}
+
+// logSlowQuery 记录慢查询，开启 SlowQueryExplain 时在后台获取执行计划后一并记录
+//
+// 同时进行的 EXPLAIN 达到 maxConcurrentExplains 时直接记录，不附带执行计划，避免数据库变慢时加倍负载
+func (c *Client) logSlowQuery(ctx context.Context, event *QueryEvent) {
+	attrs := []slog.Attr{
+		slog.String(LogKeyTable, event.Table),
+		slog.String(LogKeyAction, event.Action),
+		slog.String(LogKeySQL, event.SQL),
+		slog.Any(LogKeyArgs, redactedArgs(event.Table, event.SQL, event.args)),
+		slog.Duration(LogKeyDuration, event.Duration),
+		slog.String(LogKeyCaller, externalCaller()),
+	}
+	db := c.explainPool(event)
+	if !c.opts.SlowQueryExplain || !explainable(event.SQL) || db == nil || !c.acquireExplain() {
+		c.log(ctx, slog.LevelWarn, "database: slow query", attrs...)
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer c.releaseExplain()
+		plan, err := c.explain(ctx, db, event.SQL, event.args)
+		if err != nil {
+			attrs = append(attrs, slog.String(LogKeyPlan, "explain failed: "+err.Error()))
+		} else {
+			attrs = append(attrs, slog.Any(LogKeyPlan, plan))
+		}
+		c.log(ctx, slog.LevelWarn, "database: slow query", attrs...)
+	}()
+}
+
+// explainPool 返回执行该语句的连接池，副本上执行的查询在同一副本上 EXPLAIN
+func (c *Client) explainPool(event *QueryEvent) *pgxpool.Pool {
+	if event.replica != nil {
+		return event.replica.pool
+	}
+	return c.Client
+}
+
+// acquireExplain 占用一个 EXPLAIN 名额，已满时返回 false
+func (c *Client) acquireExplain() bool {
+	select {
+	case c.explains <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseExplain 归还 EXPLAIN 名额
+func (c *Client) releaseExplain() {
+	<-c.explains
+}
+
+// explain 在 db 上获取语句的 JSON 执行计划
+//
+// 仅在 SlowQueryExplainAnalyze 开启且语句为 SELECT 时使用 ANALYZE，写语句永远不会被实际执行
+func (c *Client) explain(ctx context.Context, db *pgxpool.Pool, sql string, args []any) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, explainKey{}, true), explainTimeout)
+	defer cancel()
+
+	stmt := "EXPLAIN (FORMAT JSON) "
+	if c.opts.SlowQueryExplainAnalyze && isSelect(sql) {
+		stmt = "EXPLAIN (ANALYZE, FORMAT JSON) "
+	}
+	var plan json.RawMessage
+	err := db.QueryRow(ctx, stmt+sql, args...).Scan(&plan)
+	return plan, err
+}
+
+// explainable 判断语句能否被 EXPLAIN
+func explainable(sql string) bool {
+	switch firstKeyword(sql) {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "VALUES", "TABLE":
+		return true
+	}
+	return false
+}
+
+var (
+	// lockingClauseRegex 行锁子句，EXPLAIN ANALYZE 会再次加锁
+	lockingClauseRegex = regexp.MustCompile(`(?i)\bFOR\s+(?:NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b`)
+	// sideEffectFuncRegex 常见的带副作用函数，EXPLAIN ANALYZE 会再次调用
+	sideEffectFuncRegex = regexp.MustCompile(`(?i)\b(?:nextval|setval|pg_advisory\w*|pg_notify|set_config|dblink\w*|lo_\w+)\s*\(`)
+)
+
+// isSelect 判断语句是否为可安全重复执行的只读查询：带写操作的 CTE、行锁子句与常见的带副作用函数不算
+//
+// 无法识别自定义的 VOLATILE 函数，调用此类函数的语句不应开启 SlowQueryExplainAnalyze
+func isSelect(sql string) bool {
+	switch firstKeyword(sql) {
+	case "SELECT", "VALUES", "TABLE":
+	case "WITH":
+		upper := strings.ToUpper(sql)
+		for _, kw := range []string{"INSERT ", "UPDATE ", "DELETE ", "MERGE "} {
+			if strings.Contains(upper, kw) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+	return !lockingClauseRegex.MatchString(sql) && !sideEffectFuncRegex.MatchString(sql)
+}
+
+func firstKeyword(sql string) string {
+	sql = strings.TrimLeft(sql, " \t\r\n(")
+	if i := strings.IndexAny(sql, " \t\r\n("); i >= 0 {
+		sql = sql[:i]
+	}
+	return strings.ToUpper(sql)
+}
+
+// externalCaller 返回调用栈中第一个不属于本库及驱动的位置
+func externalCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		// 本库的测试文件视为调用方
+		if !isInternalFrame(frame.Function) || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isInternalFrame(function string) bool {
+	for _, prefix := range []string{
+		"github.com/skadiD/database.",
+		"github.com/skadiD/database/orm.",
+		"github.com/jackc/pgx/",
+		"github.com/georgysavva/scany/",
+		"runtime.",
+	} {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
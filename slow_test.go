@@ -0,0 +1,102 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestIsSelect(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT * FROM users":                                 true,
+		"  (SELECT 1)":                                        true,
+		"WITH a AS (SELECT 1) SELECT * FROM a":                true,
+		"WITH d AS (DELETE FROM users RETURNING id) SELECT 1": false,
+		"SELECT * FROM users FOR UPDATE":                      false,
+		"SELECT * FROM users FOR NO KEY UPDATE":               false,
+		"select * from users for share skip locked":           false,
+		"SELECT * FROM users FOR KEY SHARE":                   false,
+		"WITH a AS (SELECT 1) SELECT * FROM a FOR SHARE":      false,
+		"SELECT nextval('users_id_seq')":                      false,
+		"SELECT * FROM users WHERE name = 'for sharing'":      true,
+		"UPDATE users SET name = $1":                          false,
+		"INSERT INTO users (name) VALUES ($1) RETURNING id":   false,
+	}
+	for sql, want := range cases {
+		if got := isSelect(sql); got != want {
+			t.Errorf("isSelect(%q) = %v, want %v", sql, got, want)
+		}
+	}
+	if explainable("VACUUM users") || !explainable("delete from users") {
+		t.Error("unexpected explainable result")
+	}
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	c := (&Client{opts: Options{SlowQueryThreshold: 1}}).
+		SetLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))))
+	tracer := queryTracer{c: c}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT pg_sleep(1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "database: slow query" || entry[LogKeySQL] != "SELECT pg_sleep(1)" {
+		t.Errorf("unexpected slow query entry: %v", entry)
+	}
+	if caller, _ := entry[LogKeyCaller].(string); !strings.Contains(caller, "slow_test.go") {
+		t.Errorf("caller should skip library frames, got %q", caller)
+	}
+
+	buf.Reset()
+	ctx = context.WithValue(context.Background(), explainKey{}, true)
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "EXPLAIN SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if buf.Len() != 0 {
+		t.Errorf("EXPLAIN itself should not be logged as slow: %s", buf.String())
+	}
+
+	hook := &recordHook{name: "explain", calls: &[]string{}}
+	c.AddQueryHook(hook)
+	ctx = context.WithValue(context.Background(), explainKey{}, true)
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "EXPLAIN SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	if len(*hook.calls) != 0 {
+		t.Errorf("EXPLAIN should not reach query hooks: %v", *hook.calls)
+	}
+}
+
+func TestSlowQueryExplainLimit(t *testing.T) {
+	primary := newTestPool(t)
+	r := &replica{pool: newTestPool(t)}
+	c := &Client{Client: primary, explains: make(chan struct{}, 1)}
+	if c.explainPool(&QueryEvent{}) != primary || c.explainPool(&QueryEvent{replica: r}) != r.pool {
+		t.Error("EXPLAIN should run on the pool that executed the statement")
+	}
+
+	// 名额已满时同步记录，不附带执行计划
+	var buf bytes.Buffer
+	c.opts = Options{SlowQueryThreshold: 1, SlowQueryExplain: true}
+	c.SetLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))))
+	if !c.acquireExplain() || c.acquireExplain() {
+		t.Fatal("explain semaphore should hold exactly one slot")
+	}
+	c.logSlowQuery(context.Background(), &QueryEvent{SQL: "SELECT 1"})
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", buf.String(), err)
+	}
+	if _, ok := entry[LogKeyPlan]; ok || entry["msg"] != "database: slow query" {
+		t.Errorf("unexpected slow query entry: %v", entry)
+	}
+	c.releaseExplain()
+}
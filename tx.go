@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// executor pgxpool.Pool 与 pgx.Tx 共有的执行接口
+type executor interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// TxOptions 事务选项
+type TxOptions struct {
+	// IsoLevel 隔离级别，如 pgx.Serializable，为空时使用数据库默认值
+	IsoLevel pgx.TxIsoLevel
+	// ReadOnly 只读事务
+	ReadOnly bool
+	// Deferrable 可延迟事务，仅在 SERIALIZABLE READ ONLY 下有效
+	Deferrable bool
+
+	// MaxRetries 遇到序列化失败 (40001) 或死锁 (40P01) 时重新执行整个事务的最大次数，0 表示不重试
+	//
+	// 开启后 fn 可能被执行多次，应保证其除数据库操作外没有副作用
+	MaxRetries int
+	// RetryBackoff 首次重试前的等待时间，之后每次翻倍并加入随机抖动，默认 10ms
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 单次等待时间上限，默认 1s
+	MaxRetryBackoff time.Duration
+}
+
+func (o TxOptions) pgx() pgx.TxOptions {
+	opts := pgx.TxOptions{IsoLevel: o.IsoLevel}
+	if o.ReadOnly {
+		opts.AccessMode = pgx.ReadOnly
+	}
+	if o.Deferrable {
+		opts.DeferrableMode = pgx.Deferrable
+	}
+	return opts
+}
+
+// Tx 事务
+//
+// 内嵌的 *Client 上执行的所有语句都在该事务中，可直接传给 orm.Model；
+// Context() 返回的上下文同样携带该事务，传给包级函数或 orm.WithContext 后也会加入事务
+type Tx struct {
+	*Client
+	tx  pgx.Tx
+	ctx context.Context
+}
+
+type txKey struct{}
+
+// Context 返回携带该事务的上下文
+func (t *Tx) Context() context.Context {
+	return t.ctx
+}
+
+// Raw 返回底层 pgx.Tx
+func (t *Tx) Raw() pgx.Tx {
+	return t.tx
+}
+
+// Tx 在事务中执行 fn
+//
+// fn 返回 nil 时提交，返回错误或 panic 时回滚；panic 会在回滚后继续抛出。
+// 若 c 或 ctx 上已有进行中的事务，则创建 SAVEPOINT 作为嵌套事务，此时 opts 被忽略，
+// 嵌套事务的失败只回滚到该 SAVEPOINT，是否继续由外层决定
+func (c *Client) Tx(ctx context.Context, opts TxOptions, fn func(tx *Tx) error) error {
+	if c == nil || c.Client == nil {
+		return errClientNotInitialized
+	}
+	if parent := c.activeTx(ctx); parent != nil {
+		return c.runTx(ctx, parent.Begin, fn)
+	}
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return c.Client.BeginTx(ctx, opts.pgx())
+	}
+	for attempt := 0; ; attempt++ {
+		err := c.runTx(ctx, begin, fn)
+		if err == nil || attempt >= opts.MaxRetries || !isRetryableTxErr(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(opts.backoff(attempt)):
+		}
+	}
+}
+
+// runTx 开启事务（或 SAVEPOINT）并执行 fn
+func (c *Client) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(tx *Tx) error) (err error) {
+	pgxTx, err := begin(ctx)
+	if err != nil {
+		err = classifyErr(err)
+		c.logErr(ctx, err, "", "database.Tx - Begin")
+		return err
+	}
+
+	tx := &Tx{Client: c.withTx(pgxTx), tx: pgxTx}
+	tx.ctx = context.WithValue(ctx, txKey{}, tx)
+	defer func() {
+		if r := recover(); r != nil {
+			_ = pgxTx.Rollback(context.WithoutCancel(ctx))
+			panic(r)
+		}
+		if err != nil {
+			_ = pgxTx.Rollback(context.WithoutCancel(ctx))
+			return
+		}
+		if err = classifyErr(pgxTx.Commit(ctx)); err != nil {
+			c.logErr(ctx, err, "", "database.Tx - Commit")
+		}
+	}()
+	return fn(tx)
+}
+
+// backoff 返回第 attempt 次重试前的等待时间
+func (o TxOptions) backoff(attempt int) time.Duration {
+	base, limit := o.RetryBackoff, o.MaxRetryBackoff
+	if base <= 0 {
+		base = 10 * time.Millisecond
+	}
+	if limit <= 0 {
+		limit = time.Second
+	}
+	d := base << min(attempt, 16)
+	if d <= 0 || d > limit {
+		d = limit
+	}
+	// 在 [d/2, d] 内抖动，避免冲突的事务同时重试
+	return d/2 + rand.N(d/2+1)
+}
+
+// isRetryableTxErr 判断事务是否因序列化失败或死锁而可重试
+func isRetryableTxErr(err error) bool {
+	return errors.Is(classifyErr(err), ErrSerialization)
+}
+
+// withTx 返回绑定到 tx 的 Client，除事务外与 c 的配置、钩子与副本相同
+func (c *Client) withTx(tx pgx.Tx) *Client {
+	c.typesMu.RLock()
+	defer c.typesMu.RUnlock()
+	return &Client{
+		Client:      c.Client,
+		cachedTypes: c.cachedTypes,
+		opts:        c.opts,
+		logger:      c.logger,
+		hooks:       c.hooks,
+		replicas:    c.replicas,
+		explains:    c.explains,
+		tx:          tx,
+		typesLoaded: c.typesLoaded,
+	}
+}
+
+// activeTx 返回进行中的事务：已绑定的事务或 ctx 中属于同一连接池的事务
+func (c *Client) activeTx(ctx context.Context) pgx.Tx {
+	if c.tx != nil {
+		return c.tx
+	}
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok && tx.Client.Client == c.Client {
+		return tx.tx
+	}
+	return nil
+}
+
+// conn 返回执行语句的对象：进行中的事务或连接池本身
+func (c *Client) conn(ctx context.Context) (executor, error) {
+	if c == nil || c.Client == nil {
+		return nil, errClientNotInitialized
+	}
+	if tx := c.activeTx(ctx); tx != nil {
+		return tx, nil
+	}
+	return c.Client, nil
+}
+
+// InTx c 或 ctx 上是否有进行中的事务，事务连接上的语句只能顺序执行
+func (c *Client) InTx(ctx context.Context) bool {
+	return c != nil && c.activeTx(ctx) != nil
+}
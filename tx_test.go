@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestIsRetryableTxErr(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{errors.New("40001"), false},
+	}
+	for _, c := range cases {
+		if got := isRetryableTxErr(c.err); got != c.want {
+			t.Errorf("isRetryableTxErr(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestTxOptionsBackoff(t *testing.T) {
+	opts := TxOptions{RetryBackoff: 10 * time.Millisecond, MaxRetryBackoff: 50 * time.Millisecond}
+	for attempt, want := range []time.Duration{10, 20, 40, 50, 50} {
+		want *= time.Millisecond
+		for i := 0; i < 100; i++ {
+			if d := opts.backoff(attempt); d < want/2 || d > want {
+				t.Fatalf("attempt %d: backoff %s out of [%s, %s]", attempt, d, want/2, want)
+			}
+		}
+	}
+	if d := opts.backoff(100); d > opts.MaxRetryBackoff {
+		t.Fatalf("backoff overflowed limit: %s", d)
+	}
+}
+
+func TestWithTxCopiesClient(t *testing.T) {
+	hook := &recordHook{name: "a", calls: &[]string{}}
+	c := &Client{Client: newTestPool(t), replicas: &replicaSet{}, explains: make(chan struct{}, 1)}
+	c.AddQueryHook(hook)
+
+	var tx pgx.Tx = &pgxpool.Tx{}
+	txc := c.withTx(tx)
+	if len(txc.hooks) != 1 || txc.replicas != c.replicas || txc.explains != c.explains || txc.Client != c.Client {
+		t.Fatalf("tx-bound client should share hooks, replicas and pool: %+v", txc)
+	}
+
+	// Tx 嵌入 *Client，其 Close 不应关闭共享的连接池
+	txc.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := c.Client.Acquire(ctx); err != nil && strings.Contains(err.Error(), "closed pool") {
+		t.Fatal("closing a tx-bound client closed the shared pool")
+	}
+}
@@ -1,32 +1,33 @@
 package database
 
 import (
-	"database/sql"
+	"context"
 	"errors"
-	"github.com/fexli/logger"
+	"fmt"
+	"log/slog"
 	"reflect"
-	"strings"
 )
 
-// execErr 数据表错误统一处理 无错误返回 true
-func execErr(err error, table, action string, model ...any) bool {
-	if err != nil {
-		if strings.HasPrefix(err.Error(), "ERROR: failed to connect to") {
-			return false
-		}
-		if !errors.Is(err, sql.ErrNoRows) && !strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
-			if model != nil {
-				dbLog.Debug(logger.WithContent(model))
-			}
-			dbLog.Warning(logger.WithContent("【"+table+"】<"+action+">错误"), logger.WithContent(GetFormatTrace(err, 5, false, false)), logger.WithBacktraceLevelDelta(2))
-		}
-		return false
-	}
-	return true
+// buildErr 包装并记录 SQL 构建阶段的错误
+func (c *Client) buildErr(ctx context.Context, err error, table, action string) error {
+	err = errors.Join(err, errors.New("error building SQL"))
+	c.logErr(ctx, err, table, action)
+	return err
 }
 
-func dbLogPrint(content ...logger.LogCtx) {
-	dbLog.Warning(logger.WithContent(content), logger.WithContent(GetFormatTrace(nil, 5, false, false)), logger.WithBacktraceLevelDelta(2))
+// queryErr 分类并记录 SQL 执行阶段的错误，附带 SQL 与参数，err 为 nil 时返回 nil
+func (c *Client) queryErr(ctx context.Context, err error, table, action, sql string, args []any) error {
+	if err == nil {
+		return nil
+	}
+	if table == "" {
+		table = opFromContext(ctx).table
+	}
+	logArgs := redactedArgs(table, sql, args)
+	err = errors.Join(classifyErr(err),
+		fmt.Errorf("error executing SQL:\n#### SQL:\n%s\n#### Args:\n%v", sql, logArgs))
+	c.logErr(ctx, err, table, action, slog.String(LogKeySQL, sql), slog.Any(LogKeyArgs, logArgs))
+	return err
 }
 
 func IsZeroValue(value any) bool {